@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"runtime"
+
+	"github.com/volmedo/invasim/internal/batch"
+)
+
+// batchCommand runs many independent invasions of the same world and reports aggregated statistics about them.
+func batchCommand(args []string) {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+
+	var mapFilePath string
+	flags.StringVar(&mapFilePath, "map", "", "path to a file to read the world map from")
+
+	var numAliens int
+	flags.IntVar(&numAliens, "aliens", 0, "number of aliens to unleash in every run. It must not be greater than the number of cities in the map. Defaults to the one recommended by the map file")
+
+	var runs int
+	flags.IntVar(&runs, "runs", 1000, "number of invasions to simulate")
+
+	var workers int
+	flags.IntVar(&workers, "workers", runtime.NumCPU(), "number of invasions to simulate in parallel. It doesn't affect the results")
+
+	var csvFilePath string
+	flags.StringVar(&csvFilePath, "csv", "", "path to a file to export the risk of destruction of every city to, in CSV format")
+
+	maxIterations := maxIterationsFlag(flags)
+	seed := seedFlag(flags)
+
+	_ = flags.Parse(args)
+
+	mapFile := readMapFile(flags, mapFilePath)
+
+	stats, err := batch.Run(mapFile.World, batch.Config{
+		Runs:          runs,
+		Workers:       workers,
+		NumAliens:     requireAliens(flags, numAliens, mapFile.Metadata),
+		MaxIterations: maxIterations(mapFile.Metadata),
+		Seed:          seed(),
+	})
+	if err != nil {
+		fatalf("Error running simulations: %v", err)
+	}
+
+	if err := batch.WriteReport(os.Stdout, stats); err != nil {
+		fatalf("Error writing batch report: %v", err)
+	}
+
+	if csvFilePath != "" {
+		if err := writeCityRiskCSV(csvFilePath, stats.CityRisks); err != nil {
+			fatalf("Error exporting risk of destruction per city: %v", err)
+		}
+	}
+}
+
+// writeCityRiskCSV exports risks in CSV format to the file at path.
+func writeCityRiskCSV(path string, risks []batch.CityRisk) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := batch.WriteCityRiskCSV(file, risks); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/volmedo/invasim/internal/render"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// convertCommand converts a map file from one format to another.
+func convertCommand(args []string) {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: invasim convert [flags] <path_to_map_file>")
+		flags.PrintDefaults()
+	}
+
+	formats := []string{}
+	for _, f := range worldmap.FileFormats {
+		formats = append(formats, fmt.Sprintf("%q", f))
+	}
+
+	var from string
+	flags.StringVar(&from, "from", "", fmt.Sprintf("format of the map file. One of %s. Detected from the extension or the contents of the file if not provided", strings.Join(formats, ", ")))
+
+	var to string
+	flags.StringVar(&to, "to", string(worldmap.FileFormat_Map), fmt.Sprintf("format to convert the map file to. One of %s", strings.Join(formats, ", ")))
+
+	var outFilePath string
+	flags.StringVar(&outFilePath, "out", "", "path to a file to write the converted map file to. Standard output is used if not provided")
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("a path to a map file is required")
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	toFormat := fileFormatFlag(flags, "to", to)
+
+	mapFilePath := flags.Arg(0)
+	contents, err := os.ReadFile(mapFilePath)
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	fromFormat := worldmap.DetectFileFormat(mapFilePath, contents)
+	if from != "" {
+		fromFormat = fileFormatFlag(flags, "from", from)
+	}
+
+	mapFile, err := worldmap.ParseFormat(bytes.NewReader(contents), fromFormat)
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	out, closeOut := createOutput(outFilePath)
+
+	opts := worldmap.FormatOptions{
+		Order:    mapFile.Order,
+		Metadata: mapFile.Metadata,
+		Comments: &mapFile.Comments,
+		Layout:   mapFile.Layout,
+	}
+	if err := writeMapFile(out, mapFile, toFormat, opts); err != nil {
+		fatalf("Error converting map file: %v", err)
+	}
+	closeOut()
+}
+
+// fileFormatFlag parses the value of the flag with the given name as a map file format, exiting with a meaningful
+// message if it is not a known one.
+func fileFormatFlag(flags *flag.FlagSet, name, value string) worldmap.FileFormat {
+	for _, f := range worldmap.FileFormats {
+		if value == string(f) {
+			return f
+		}
+	}
+
+	fmt.Printf("-%s: unknown format %q\n", name, value)
+	flags.Usage()
+	os.Exit(42)
+
+	return ""
+}
+
+// writeMapFile writes the world of mapFile to out as a map file in the given format. Grids are drawn with
+// render.ASCII, placing cities in the positions declared in mapFile if possible.
+func writeMapFile(out io.Writer, mapFile *worldmap.MapFile, format worldmap.FileFormat, opts worldmap.FormatOptions) error {
+	if format == worldmap.FileFormat_Grid {
+		return render.ASCII(out, mapFile.World, newLayout(mapFile))
+	}
+
+	return worldmap.FormatAs(out, mapFile.World, format, opts)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// exportCommand exports a world in a graphical format, optionally overlaying aliens on it or showing what it looks
+// like after an invasion.
+func exportCommand(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+
+	var mapFilePath string
+	flags.StringVar(&mapFilePath, "map", "", "path to a file to read the world map from")
+
+	var format string
+	flags.StringVar(&format, "format", "svg", "format to export the world in. One of \"dot\" (Graphviz) or \"svg\"")
+
+	var outFilePath string
+	flags.StringVar(&outFilePath, "out", "", "path to a file to export the world to. Standard output is used if not provided")
+
+	var numAliens int
+	flags.IntVar(&numAliens, "aliens", 0, "number of aliens to place in the world. Their positions are overlaid on the world")
+
+	var invade bool
+	flags.BoolVar(&invade, "invade", false, "simulate an invasion before exporting the world, highlighting the cities destroyed in it. Requires -aliens")
+
+	maxIterations := maxIterationsFlag(flags)
+	seed := seedFlag(flags)
+
+	_ = flags.Parse(args)
+
+	var encode func(io.Writer, worldmap.World, worldmap.EncodeOptions) error
+	switch format {
+	case "dot":
+		encode = worldmap.EncodeDOT
+	case "svg":
+		encode = worldmap.EncodeSVG
+	default:
+		fmt.Printf("-format: unknown format %q\n", format)
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	if invade && numAliens == 0 {
+		fmt.Println("-invade: a number of aliens greater than 0 is required to simulate an invasion")
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	mapFile := readMapFile(flags, mapFilePath)
+	world := mapFile.World
+
+	layout := newLayout(mapFile)
+
+	opts := worldmap.EncodeOptions{Layout: layout}
+	if numAliens > 0 {
+		rng := newRand(seed())
+		alienTracker, err := aliens.NewTracker(numAliens, world, nil, rng)
+		if err != nil {
+			fatalf("Error placing aliens on their starting positions: %v", err)
+		}
+
+		if invade {
+			simulation.Run(world, alienTracker, maxIterations(mapFile.Metadata), rng, nil, simulation.Options{})
+		}
+
+		opts.Aliens = alienTracker.Cities()
+	}
+
+	out, closeOut := createOutput(outFilePath)
+
+	if err := encode(out, world, opts); err != nil {
+		fatalf("Error exporting the world: %v", err)
+	}
+	closeOut()
+}
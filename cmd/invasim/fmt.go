@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// fmtCommand rewrites a map file in canonical form, keeping its format.
+func fmtCommand(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: invasim fmt [flags] <path_to_map_file>")
+		flags.PrintDefaults()
+	}
+
+	var write bool
+	flags.BoolVar(&write, "w", false, "write the result to the map file instead of standard output")
+
+	var keepOrder bool
+	flags.BoolVar(&keepOrder, "keep-order", false, "keep cities in the order they are first mentioned in the map file instead of sorting them alphabetically")
+
+	var stripComments bool
+	flags.BoolVar(&stripComments, "strip-comments", false, "remove comments instead of keeping them along the cities they precede")
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("a path to a map file is required")
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	mapFilePath := flags.Arg(0)
+	original, err := os.ReadFile(mapFilePath)
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	format := worldmap.DetectFileFormat(mapFilePath, original)
+	mapFile, err := worldmap.ParseFormat(bytes.NewReader(original), format)
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	opts := worldmap.FormatOptions{Metadata: mapFile.Metadata, Layout: mapFile.Layout}
+	if keepOrder {
+		opts.Order = mapFile.Order
+	}
+	if !stripComments {
+		opts.Comments = &mapFile.Comments
+	}
+
+	formatted := &bytes.Buffer{}
+	if err := writeMapFile(formatted, mapFile, format, opts); err != nil {
+		fatalf("Error formatting map file: %v", err)
+	}
+
+	if !write {
+		if _, err := formatted.WriteTo(os.Stdout); err != nil {
+			fatalf("Error writing formatted map file: %v", err)
+		}
+
+		return
+	}
+
+	if bytes.Equal(original, formatted.Bytes()) {
+		return
+	}
+
+	info, err := os.Stat(mapFilePath)
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	if err := os.WriteFile(mapFilePath, formatted.Bytes(), info.Mode().Perm()); err != nil {
+		fatalf("Error writing formatted map file: %v", err)
+	}
+}
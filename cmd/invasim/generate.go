@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/volmedo/invasim/internal/generator"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// generateCommand writes a procedurally generated world to a map file.
+func generateCommand(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+
+	kinds := []string{}
+	for _, k := range generator.Kinds {
+		kinds = append(kinds, fmt.Sprintf("%q", k))
+	}
+
+	var kind string
+	flags.StringVar(&kind, "kind", string(generator.Kind_Grid), fmt.Sprintf("kind of world to generate. One of %s", strings.Join(kinds, ", ")))
+
+	cfg := generator.Config{}
+	flags.IntVar(&cfg.Width, "width", 10, "width of the grid the world is generated on, or of every island")
+	flags.IntVar(&cfg.Height, "height", 10, "height of the grid the world is generated on, or of every island")
+	flags.Float64Var(&cfg.Density, "density", 0.3, "probability of every road to be removed, between 0 and 1. Only used by \"sparse\" worlds")
+	flags.IntVar(&cfg.Islands, "islands", 3, "number of islands. Only used by \"islands\" worlds")
+
+	formats := []string{}
+	for _, f := range worldmap.FileFormats {
+		formats = append(formats, fmt.Sprintf("%q", f))
+	}
+
+	var to string
+	flags.StringVar(&to, "to", string(worldmap.FileFormat_Map), fmt.Sprintf("format of the map file. One of %s", strings.Join(formats, ", ")))
+
+	var outFilePath string
+	flags.StringVar(&outFilePath, "out", "", "path to a file to write the map file to. Standard output is used if not provided")
+
+	var numAliens int
+	flags.IntVar(&numAliens, "aliens", 0, "number of aliens to recommend in the metadata of the map file. None is recommended if not provided")
+
+	seed := seedFlag(flags)
+
+	_ = flags.Parse(args)
+
+	cfg.Kind = generator.Kind(kind)
+	toFormat := fileFormatFlag(flags, "to", to)
+
+	s := seed()
+	world, layout, err := generator.Generate(cfg, newRand(s))
+	if err != nil {
+		fatalf("Error generating the world: %v", err)
+	}
+
+	out, closeOut := createOutput(outFilePath)
+
+	mapFile := &worldmap.MapFile{World: world, Layout: layout}
+	opts := worldmap.FormatOptions{
+		Metadata: worldmap.Metadata{
+			Name:   fmt.Sprintf("%s %dx%d", cfg.Kind, cfg.Width, cfg.Height),
+			Aliens: numAliens,
+			Extra:  map[string]string{"seed": fmt.Sprint(s)},
+		},
+		Layout: layout,
+	}
+	if err := writeMapFile(out, mapFile, toFormat, opts); err != nil {
+		fatalf("Error writing map file: %v", err)
+	}
+	closeOut()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// lintCommand reports every problem found in a map file. It only exits with a non-zero status if any of those
+// problems is an error.
+func lintCommand(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: invasim lint <path_to_map_file>")
+		flags.PrintDefaults()
+	}
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("a path to a map file is required")
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	mapFilePath := flags.Arg(0)
+	contents, err := os.ReadFile(mapFilePath)
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	// only the line-based format can be linted: problems in JSON and YAML map files are reported one at a time, as
+	// they are found while reading them
+	if format := worldmap.DetectFileFormat(mapFilePath, contents); format != worldmap.FileFormat_Map {
+		if _, err := worldmap.ParseFormat(bytes.NewReader(contents), format); err != nil {
+			fmt.Printf("%s: %s: %v\n", mapFilePath, worldmap.Severity_Error, err)
+			os.Exit(42)
+		}
+
+		return
+	}
+
+	diagnostics, err := worldmap.Lint(bytes.NewReader(contents))
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	hasErrors := false
+	for _, d := range diagnostics {
+		fmt.Printf("%s:%s\n", mapFilePath, d)
+		if d.Severity == worldmap.Severity_Error {
+			hasErrors = true
+		}
+	}
+
+	if hasErrors {
+		os.Exit(42)
+	}
+}
@@ -3,47 +3,194 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
+	"time"
 
 	"github.com/volmedo/invasim/internal/aliens"
-	"github.com/volmedo/invasim/internal/simulation"
 	"github.com/volmedo/invasim/internal/worldmap"
 )
 
 const MAX_ITERATIONS = 10_000
 
-func main() {
-	var mapFilePath string
-	flag.StringVar(&mapFilePath, "map", "", "path to a file to read the world map from")
+// usage is printed when the command given in the command line is not known.
+const usage = `usage: invasim [command] [flags]
+
+Available commands:
+    run      run a single invasion (default)
+    batch    run many invasions and aggregate their outcomes
+    render   draw a world before and after an invasion
+    export   export a world as a Graphviz graph or an SVG image
+    lint     report every problem found in a map file
+    fmt      rewrite a map file in canonical form
+    convert  convert a map file to a different format
+    generate generate a new world procedurally
+
+Run 'invasim <command> -h' to get help about the flags each command accepts.
+`
 
-	var numAliens int
-	flag.IntVar(&numAliens, "aliens", 0, "number of aliens to unleash. It must not be greater than the number of cities in the map")
+func main() {
+	command, args := "run", os.Args[1:]
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
 
-	flag.Parse()
+	switch command {
+	case "run":
+		runCommand(args)
+	case "batch":
+		batchCommand(args)
+	case "render":
+		renderCommand(args)
+	case "export":
+		exportCommand(args)
+	case "lint":
+		lintCommand(args)
+	case "fmt":
+		fmtCommand(args)
+	case "convert":
+		convertCommand(args)
+	case "generate":
+		generateCommand(args)
+	default:
+		fmt.Print(usage)
+		os.Exit(42)
+	}
+}
 
+// readMapFile parses the map file at mapFilePath, exiting with a meaningful message if it can't.
+func readMapFile(flags *flag.FlagSet, mapFilePath string) *worldmap.MapFile {
 	if mapFilePath == "" {
 		fmt.Println("-map: a path to a map file is required and cannot be blank")
-		flag.Usage()
+		flags.Usage()
 		os.Exit(42)
 	}
 
+	mapFile, err := worldmap.ParseFile(mapFilePath)
+	if err != nil {
+		fatalf("Error reading map file: %v", err)
+	}
+
+	return mapFile
+}
+
+// newLayout returns the coordinates declared in mapFile if every city has them, or computes a new Layout for its
+// world otherwise. It exits with a meaningful message if the world can't be laid out.
+func newLayout(mapFile *worldmap.MapFile) worldmap.Layout {
+	if len(mapFile.Layout) == len(mapFile.World) && len(mapFile.Layout) > 0 {
+		return mapFile.Layout
+	}
+
+	layout, err := worldmap.NewLayout(mapFile.World)
+	if err != nil {
+		fatalf("Error laying out the world: %v", err)
+	}
+
+	return layout
+}
+
+// requireAliens returns numAliens or, if it is 0, the number of aliens recommended by metadata. It exits with a
+// meaningful message if neither of them is greater than 0.
+func requireAliens(flags *flag.FlagSet, numAliens int, metadata worldmap.Metadata) int {
 	if numAliens == 0 {
-		fmt.Println("-aliens: a number of aliens greater than 0 is required")
-		flag.Usage()
+		numAliens = metadata.Aliens
+	}
+
+	if numAliens <= 0 {
+		fmt.Println("-aliens: a number of aliens greater than 0 is required, as the map file doesn't recommend any")
+		flags.Usage()
 		os.Exit(42)
 	}
 
-	world, err := worldmap.ReadFromFile(mapFilePath)
-	if err != nil {
-		fatalf("Error reading map file: %v", err)
+	return numAliens
+}
+
+// maxIterationsFlag registers the -max-iterations flag in flags. The returned function gives the value of the flag
+// once flags have been parsed or, if it wasn't set, the maximum number of iterations recommended by the metadata of
+// the map file, falling back to MAX_ITERATIONS.
+func maxIterationsFlag(flags *flag.FlagSet) func(worldmap.Metadata) int {
+	var maxIterations int
+	flags.IntVar(&maxIterations, "max-iterations", 0, fmt.Sprintf("maximum number of iterations of every simulation. Defaults to the one recommended by the map file, or %d if there is none", MAX_ITERATIONS))
+
+	return func(metadata worldmap.Metadata) int {
+		switch {
+		case maxIterations > 0:
+			return maxIterations
+		case metadata.MaxIterations > 0:
+			return metadata.MaxIterations
+		default:
+			return MAX_ITERATIONS
+		}
+	}
+}
+
+// seedFlag registers the -seed flag in flags. The returned function gives the value of the seed once flags have been
+// parsed, or a random one if it wasn't explicitly set. In that case the seed is printed to standard error so the
+// run can be repeated later.
+func seedFlag(flags *flag.FlagSet) func() int64 {
+	var seed int64
+	flags.Int64Var(&seed, "seed", 0, "seed for the random number generator. Runs with the same map, number of aliens and seed produce the same output. A random seed is used if not provided")
+
+	return func() int64 {
+		if !isFlagSet(flags, "seed") {
+			seed = time.Now().UnixNano()
+			fmt.Fprintf(os.Stderr, "Using random seed %d\n", seed)
+		}
+
+		return seed
 	}
+}
+
+// distributionFlag returns a function that parses the value of a flag as an aliens.Distribution, storing it in d. It
+// is meant to be used with flag.FlagSet.Func.
+func distributionFlag(d *aliens.Distribution) func(string) error {
+	return func(value string) error {
+		parsed, err := aliens.ParseDistribution(value)
+		if err != nil {
+			return err
+		}
 
-	alienTracker, err := aliens.NewTracker(numAliens, world)
+		*d = parsed
+		return nil
+	}
+}
+
+// newRand creates a new random number generator using the given seed.
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// isFlagSet reports whether the flag with the given name was explicitly set in the command line.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// createOutput creates the file at path to write the output of a command to, or returns standard output if path is
+// empty. The returned function must be called once the output is written: it closes the file, exiting with a
+// meaningful message if its contents couldn't be saved. It exits as well if the file can't be created.
+func createOutput(path string) (io.Writer, func()) {
+	if path == "" {
+		return os.Stdout, func() {}
+	}
+
+	file, err := os.Create(path)
 	if err != nil {
-		fatalf("Error placing aliens on their starting positions: %v", err)
+		fatalf("Error creating output file: %v", err)
 	}
 
-	simulation.Run(world, alienTracker, MAX_ITERATIONS, os.Stdout)
+	return file, func() {
+		if err := file.Close(); err != nil {
+			fatalf("Error saving output file: %v", err)
+		}
+	}
 }
 
 func fatalf(format string, v ...any) {
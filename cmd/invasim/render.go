@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/render"
+	"github.com/volmedo/invasim/internal/simulation"
+)
+
+// renderCommand draws a world and, optionally, what it looks like after an invasion.
+func renderCommand(args []string) {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+
+	var mapFilePath string
+	flags.StringVar(&mapFilePath, "map", "", "path to a file to read the world map from")
+
+	var format string
+	flags.StringVar(&format, "format", "ascii", "format of the drawing. Only \"ascii\" is supported for now")
+
+	var numAliens int
+	flags.IntVar(&numAliens, "aliens", 0, "number of aliens to unleash. If greater than 0, the world is drawn again after the invasion")
+
+	maxIterations := maxIterationsFlag(flags)
+	seed := seedFlag(flags)
+
+	_ = flags.Parse(args)
+
+	if format != "ascii" {
+		fmt.Printf("-format: unknown format %q\n", format)
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	mapFile := readMapFile(flags, mapFilePath)
+	world := mapFile.World
+
+	layout := newLayout(mapFile)
+
+	fmt.Println("This is what the world looks like before the invasion:")
+	if err := render.ASCII(os.Stdout, world, layout); err != nil {
+		fatalf("Error drawing the world: %v", err)
+	}
+
+	if numAliens == 0 {
+		return
+	}
+
+	rng := newRand(seed())
+	alienTracker, err := aliens.NewTracker(numAliens, world, nil, rng)
+	if err != nil {
+		fatalf("Error placing aliens on their starting positions: %v", err)
+	}
+
+	simulation.Run(world, alienTracker, maxIterations(mapFile.Metadata), rng, nil, simulation.Options{})
+
+	fmt.Println()
+	fmt.Println("This is what the world looks like after the invasion:")
+	if err := render.ASCII(os.Stdout, world, layout); err != nil {
+		fatalf("Error drawing the world: %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/generator"
+	"github.com/volmedo/invasim/internal/render"
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// runCommand runs a single invasion and reports what happens in it.
+func runCommand(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+
+	var mapFilePath string
+	flags.StringVar(&mapFilePath, "map", "", "path to a file to read the world map from")
+
+	var numAliens int
+	flags.IntVar(&numAliens, "aliens", 0, "number of aliens to unleash. It must not be greater than the number of cities in the map. Defaults to the one recommended by the map file")
+
+	maxIterations := maxIterationsFlag(flags)
+	seed := seedFlag(flags)
+
+	var eventsFormat string
+	flags.StringVar(&eventsFormat, "events-format", "text", "format used to report simulation events. One of \"text\" (human-readable messages) or \"jsonl\" (one JSON object per event and line)")
+
+	var gifFilePath string
+	flags.StringVar(&gifFilePath, "gif", "", "path to a file to save an animation of the invasion to, in GIF format")
+
+	opts := simulation.Options{}
+	flags.IntVar(&opts.Parallelism, "parallelism", 0, "number of goroutines aliens are moved by. If 0, aliens are moved one after another. The outcome for a given seed is the same no matter the value")
+
+	var strategyName string
+	flags.StringVar(&strategyName, "strategy", aliens.StrategyName_Uniform, fmt.Sprintf("how aliens choose where to move to. One of %q", aliens.StrategyNames))
+
+	var trappedPolicy string
+	flags.StringVar(&trappedPolicy, "trapped", string(simulation.TrappedPolicy_Keep), fmt.Sprintf("what happens to aliens left without roads to follow. One of %q", simulation.TrappedPolicies))
+	flags.IntVar(&opts.DigOutTurns, "dig-out-turns", 3, "number of iterations trapped aliens wait before digging out to a random city. Only used with -trapped dig-out")
+
+	var crossingRule string
+	flags.StringVar(&crossingRule, "crossings", string(simulation.CrossingRule_Ignore), fmt.Sprintf("what happens to aliens that cross each other on a road. One of %q", simulation.CrossingRules))
+
+	var battleRule string
+	flags.StringVar(&battleRule, "battle-rule", simulation.BattleRuleName_Damage, fmt.Sprintf("how battles end. One of %q", simulation.BattleRuleNames))
+	battleOpts := simulation.BattleOptions{}
+	flags.BoolVar(&battleOpts.CitySurvives, "city-survives", false, "keep cities standing after battles won by an alien. Only used with -battle-rule last-alien-standing or strength")
+	flags.IntVar(&battleOpts.Defences, "defences", 1, "number of battles every city can withstand. Only used with -battle-rule defences")
+
+	attrs := aliens.Attributes{}
+	flags.Func("health", "health points of every alien, either a number or a range like 1-5 to draw them from at random. Defaults to 1, or the value in the placement file", distributionFlag(&attrs.Health))
+	flags.Func("strength", "strength of every alien, either a number or a range like 1-5 to draw it from at random. Defaults to 1, or the value in the placement file", distributionFlag(&attrs.Strength))
+	flags.Func("speed", "number of roads every alien takes per iteration, either a number or a range like 1-5 to draw it from at random. Defaults to 1, or the value in the placement file", distributionFlag(&attrs.Speed))
+
+	var namerName, namesFilePath string
+	flags.StringVar(&namerName, "names", aliens.NamerName_Random, fmt.Sprintf("how aliens placed at random are named. One of %q", aliens.NamerNames))
+	flags.StringVar(&namesFilePath, "names-file", "", "path to a file listing the names of aliens placed at random, one per line. It takes precedence over -names")
+
+	var placementFilePath string
+	flags.StringVar(&placementFilePath, "placement", "", "path to a placement file declaring where every alien starts and, optionally, its own strategy. Aliens are placed at random if not provided")
+
+	var infinite bool
+	flags.BoolVar(&infinite, "infinite", false, "invade an infinite grid of cities generated on the fly instead of the world in a map file")
+
+	infiniteCfg := generator.InfiniteConfig{}
+	flags.Float64Var(&infiniteCfg.Density, "density", 0.3, "probability of every road of the infinite grid to be removed, between 0 and 1. Only used with -infinite")
+	flags.IntVar(&infiniteCfg.SpawnRadius, "spawn-radius", 50, "maximum distance from the origin of the infinite grid of the cities where aliens start. Only used with -infinite")
+
+	_ = flags.Parse(args)
+
+	var observer simulation.Observer
+	var jsonlObserver *simulation.JSONLObserver
+	switch eventsFormat {
+	case "text":
+		observer = simulation.NewTextObserver(os.Stdout)
+	case "jsonl":
+		jsonlObserver = simulation.NewJSONLObserver(os.Stdout)
+		observer = jsonlObserver
+	default:
+		fmt.Printf("-events-format: unknown format %q\n", eventsFormat)
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	if strategyName != aliens.StrategyName_Uniform {
+		strategy, err := aliens.NewStrategy(strategyName)
+		if err != nil {
+			fmt.Printf("-strategy: %v\n", err)
+			flags.Usage()
+			os.Exit(42)
+		}
+		opts.Strategy = strategy
+	}
+
+	policy, err := simulation.ParseTrappedPolicy(trappedPolicy)
+	if err != nil {
+		fmt.Printf("-trapped: %v\n", err)
+		flags.Usage()
+		os.Exit(42)
+	}
+	opts.TrappedPolicy = policy
+
+	rule, err := simulation.ParseCrossingRule(crossingRule)
+	if err != nil {
+		fmt.Printf("-crossings: %v\n", err)
+		flags.Usage()
+		os.Exit(42)
+	}
+	opts.CrossingRule = rule
+
+	resolver, err := simulation.NewBattleResolver(battleRule, battleOpts)
+	if err != nil {
+		fmt.Printf("-battle-rule: %v\n", err)
+		flags.Usage()
+		os.Exit(42)
+	}
+	opts.BattleResolver = resolver
+
+	namer := newNamer(flags, namerName, namesFilePath)
+
+	if infinite {
+		if gifFilePath != "" {
+			fmt.Println("-gif: infinite worlds can't be animated")
+			flags.Usage()
+			os.Exit(42)
+		}
+
+		runInfinite(flags, infiniteCfg, numAliens, placementFilePath, namer, attrs, maxIterations(worldmap.Metadata{}), seed(), observer, jsonlObserver, opts)
+
+		return
+	}
+
+	mapFile := readMapFile(flags, mapFilePath)
+	world := mapFile.World
+	rng := newRand(seed())
+	alienTracker := placeAliens(flags, placementFilePath, numAliens, namer, attrs, mapFile.Metadata, world, rng, &opts)
+
+	var gifRecorder *render.GIFRecorder
+	if gifFilePath != "" {
+		gifRecorder = render.NewGIFRecorder(world, newLayout(mapFile))
+		observer = simulation.Observers{observer, gifRecorder}
+	}
+
+	result := simulation.Run(world, alienTracker, maxIterations(mapFile.Metadata), rng, observer, opts)
+
+	if gifRecorder != nil {
+		if err := writeGIF(gifFilePath, gifRecorder); err != nil {
+			fatalf("Error saving animation: %v", err)
+		}
+	}
+
+	reportResult(result, jsonlObserver)
+}
+
+// runInfinite runs a single invasion of an infinite grid of cities described by cfg, generated from seed.
+func runInfinite(
+	flags *flag.FlagSet,
+	cfg generator.InfiniteConfig,
+	numAliens int,
+	placementFilePath string,
+	namer aliens.Namer,
+	attrs aliens.Attributes,
+	maxIterations int,
+	seed int64,
+	observer simulation.Observer,
+	jsonlObserver *simulation.JSONLObserver,
+	opts simulation.Options,
+) {
+	cfg.Seed = seed
+	world, err := generator.NewInfiniteGrid(cfg)
+	if err != nil {
+		fatalf("Error generating the world: %v", err)
+	}
+
+	rng := newRand(seed)
+	alienTracker := placeAliens(flags, placementFilePath, numAliens, namer, attrs, worldmap.Metadata{}, world, rng, &opts)
+
+	result := simulation.Run(world, alienTracker, maxIterations, rng, observer, opts)
+	reportResult(result, jsonlObserver)
+}
+
+// placeAliens places the aliens declared in the placement file at placementFilePath in world, setting their
+// strategies in opts. If no placement file is given, numAliens aliens, or as many as recommended by metadata, are
+// placed at random instead and named by namer. Attributes not declared in the placement file are drawn from attrs.
+// It exits with a meaningful message if aliens can't be placed.
+func placeAliens(
+	flags *flag.FlagSet,
+	placementFilePath string,
+	numAliens int,
+	namer aliens.Namer,
+	attrs aliens.Attributes,
+	metadata worldmap.Metadata,
+	world worldmap.Terrain,
+	rng *rand.Rand,
+	opts *simulation.Options,
+) aliens.Tracker {
+	if placementFilePath == "" {
+		numAliens = requireAliens(flags, numAliens, metadata)
+		alienTracker, err := aliens.NewTracker(numAliens, world, namer, rng)
+		if err != nil {
+			fatalf("Error placing aliens on their starting positions: %v", err)
+		}
+		alienTracker.Equip(attrs, rng)
+
+		return alienTracker
+	}
+
+	placement, err := aliens.ReadPlacementFile(placementFilePath)
+	if err != nil {
+		fatalf("Error reading placement file: %v", err)
+	}
+
+	for _, a := range placement.Order {
+		if !isCityOf(world, placement.Tracker[a].City) {
+			fatalf("Error reading placement file: alien %s starts in %s, which is not part of the world", a, placement.Tracker[a].City)
+		}
+	}
+
+	opts.Strategies = placement.Strategies
+	placement.Tracker.Equip(attrs, rng)
+
+	return placement.Tracker
+}
+
+// isCityOf reports whether city is one of the cities of world.
+func isCityOf(world worldmap.Terrain, city string) bool {
+	switch w := world.(type) {
+	case worldmap.World:
+		_, exists := w[city]
+		return exists
+	case *worldmap.Compact:
+		_, exists := w.ID(city)
+		return exists
+	case *generator.InfiniteGrid:
+		_, valid := generator.ParseCityName(city)
+		return valid
+	default:
+		return true
+	}
+}
+
+// newNamer returns the Namer for aliens placed at random: the names listed in the file at namesFilePath if given, or
+// the built-in Namer called namerName otherwise. It exits with a meaningful message if neither can be used.
+func newNamer(flags *flag.FlagSet, namerName, namesFilePath string) aliens.Namer {
+	if namesFilePath != "" {
+		names, err := aliens.ReadNamesFile(namesFilePath)
+		if err != nil {
+			fatalf("Error reading names file: %v", err)
+		}
+
+		return names
+	}
+
+	namer, err := aliens.NewNamer(namerName)
+	if err != nil {
+		fmt.Printf("-names: %v\n", err)
+		flags.Usage()
+		os.Exit(42)
+	}
+
+	return namer
+}
+
+// reportResult writes a summary of result to standard output, unless events are being reported in JSON Lines format
+// by jsonlObserver, in which case only the errors found while encoding them are reported.
+func reportResult(result simulation.Result, jsonlObserver *simulation.JSONLObserver) {
+	if jsonlObserver != nil {
+		if err := jsonlObserver.Err(); err != nil {
+			fatalf("Error writing events: %v", err)
+		}
+
+		return
+	}
+
+	if err := simulation.WriteReport(os.Stdout, result); err != nil {
+		fatalf("Error writing simulation report: %v", err)
+	}
+}
+
+// writeGIF saves the animation recorded by recorder to the file at path.
+func writeGIF(path string, recorder *render.GIFRecorder) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := recorder.Encode(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
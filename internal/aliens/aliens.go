@@ -3,46 +3,85 @@ package aliens
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
 
 	"github.com/volmedo/invasim/internal/worldmap"
 )
 
-// Tracker keeps track of the city each alien is currently at
-type Tracker map[string]string
-
-// NewTracker creates a new alien Tracker with numAliens aliens placed randomly in one of the cities of world.
-// Since there can only be an alien in a city, numAliens cannot be greater than the number of cities in world.
-func NewTracker(numAliens int, world worldmap.World) (Tracker, error) {
-	if numAliens > len(world) {
-		return Tracker{}, fmt.Errorf("not enough cities (%d) to place %d aliens", len(world), numAliens)
-	}
-
-	tracker := Tracker{}
+// Alien is an alien taking part in an invasion: where it is and what it is capable of. Attributes left to zero are
+// unset, and take their default values once the invasion starts (see WithDefaults).
+type Alien struct {
+	// City is the city the alien is currently at.
+	City string
+	// Health is the number of health points the alien has left. Aliens are destroyed once they run out of them.
+	Health int
+	// Strength is the number of health points the alien takes from every other alien it fights with.
+	Strength int
+	// Speed is the number of roads the alien takes in every iteration.
+	Speed int
+	// Moves is the number of roads the alien has taken so far.
+	Moves int
+}
 
-	randomCities := randomizeCities(world)
-	randomCities = randomCities[:numAliens]
+// Default values of the attributes of aliens.
+const (
+	DefaultHealth   = 1
+	DefaultStrength = 1
+	DefaultSpeed    = 1
+)
 
-	for _, city := range randomCities {
-		name := randomAlienName()
-		tracker[name] = city
+// WithDefaults returns a copy of a where unset attributes take their default values. Aliens with default attributes
+// are destroyed in their first battle, and take a single road per iteration.
+func (a Alien) WithDefaults() Alien {
+	if a.Health == 0 {
+		a.Health = DefaultHealth
+	}
+	if a.Strength == 0 {
+		a.Strength = DefaultStrength
+	}
+	if a.Speed == 0 {
+		a.Speed = DefaultSpeed
 	}
 
-	return tracker, nil
+	return a
 }
 
-// randomizeCities returns a slice with the names of the cities in world in a random order.
-func randomizeCities(world worldmap.World) []string {
-	randomCities := make([]string, 0, len(world))
-	for city := range world {
-		randomCities = append(randomCities, city)
+// Tracker keeps track of every alien, by name.
+type Tracker map[string]Alien
+
+// NewTracker creates a new alien Tracker with numAliens aliens placed randomly in one of the cities of world and named
+// by namer, or given random names if namer is nil.
+// Since there can only be an alien in a city, numAliens cannot be greater than the number of cities in world. Every
+// alien gets a name of its own, so an error is returned as well if namer can't come up with numAliens different names.
+// All random decisions are taken using rng, so the same world, number of aliens, namer and seed always produce the same
+// Tracker.
+func NewTracker(numAliens int, world worldmap.Terrain, namer Namer, rng *rand.Rand) (Tracker, error) {
+	randomCities, err := world.RandomCities(numAliens, rng)
+	if err != nil {
+		return Tracker{}, err
 	}
 
-	rand.Shuffle(len(randomCities), func(i, j int) {
-		randomCities[i], randomCities[j] = randomCities[j], randomCities[i]
-	})
+	if namer == nil {
+		namer = RandomNames{}
+	}
+	names, err := namer.Names(len(randomCities), rng)
+	if err != nil {
+		return Tracker{}, err
+	}
+	if len(names) != len(randomCities) {
+		return Tracker{}, fmt.Errorf("got %d names for %d aliens", len(names), len(randomCities))
+	}
 
-	return randomCities
+	tracker := Tracker{}
+	for i, city := range randomCities {
+		if _, taken := tracker[names[i]]; taken {
+			return Tracker{}, fmt.Errorf("name %s given to more than one alien", names[i])
+		}
+		tracker[names[i]] = Alien{City: city}
+	}
+
+	return tracker, nil
 }
 
 var vowels = []string{"a", "e", "i", "o", "u"}
@@ -51,73 +90,53 @@ var alphabet = append(consonants, vowels...)
 
 // randomAlienName creates a random alien name between 4 and 8 characters long, with a 30% chance of having a hyphen
 // for extra alienness. Thanks ChatGPT.
-func randomAlienName() string {
-	length := rand.Intn(4) + 4
-	name := []string{strings.ToUpper(vowels[rand.Intn(len(vowels))])}
+func randomAlienName(rng *rand.Rand) string {
+	length := rng.Intn(4) + 4
+	name := []string{strings.ToUpper(vowels[rng.Intn(len(vowels))])}
 	for i := 0; i < length-2; i++ {
-		name = append(name, alphabet[rand.Intn(len(alphabet))])
+		name = append(name, alphabet[rng.Intn(len(alphabet))])
 	}
-	name = append(name, vowels[rand.Intn(len(vowels))])
+	name = append(name, vowels[rng.Intn(len(vowels))])
 	nameStr := ""
 	for _, c := range name {
 		nameStr += c
 	}
-	if rand.Float64() < 0.3 {
-		index := rand.Intn(length-2) + 1
+	if rng.Float64() < 0.3 {
+		index := rng.Intn(length-2) + 1
 		nameStr = nameStr[:index] + "-" + nameStr[index:]
 	}
 	return nameStr
 }
 
-// VisitedCities offers the opposite view than what Aliens provides. It maps each city being visited to a list of
-// aliens currently placed in that location. Cities with no alien presence will not appear in this map. It is used
-// as an auxiliary data structure to enable quick checking of cities and aliens that should be destroyed during fights.
-type VisitedCities map[string][]string
-
-// MoveRandomly moves all the aliens in the Tracker randomly through one the roads available from the city each of them
-// is currently at. Once an available road is chosen, the alien's position is updated to the destination.
-// As the function moves aliens around, it also collects visited cities to make checking which cities have more than
-// one alien more convenient.
-func (t Tracker) MoveRandomly(world worldmap.World) VisitedCities {
-	visited := VisitedCities{}
-	for a, currCity := range t {
-		roads := world[currCity]
-		if len(roads) == 0 {
-			// TODO: consider the possibility of removing the alien from the tracker, as it won't be able to move any further
-			continue
-		}
-
-		destCity := pickRandomDestination(roads)
-
-		t[a] = destCity
-
-		visited[destCity] = append(visited[destCity], a)
+// Names returns the names of the aliens in the Tracker in alphabetical order.
+func (t Tracker) Names() []string {
+	names := make([]string, 0, len(t))
+	for a := range t {
+		names = append(names, a)
 	}
+	sort.Strings(names)
 
-	return visited
+	return names
 }
 
-// pickRandomDestination picks a random road from the set of roads being passed and return the city it leads to.
-// It does so by choosing a random index and enumerating the available roads until the chosen index is found.
-func pickRandomDestination(roads worldmap.Roads) string {
-	randIdx := rand.Intn(len(roads))
-
-	var destCity string
-	i := 0
-	for _, destCity = range roads {
-		if i == randIdx {
-			break
-		}
-
-		i++
+// Cities returns a map of the names of the aliens in the Tracker to the cities they are at.
+func (t Tracker) Cities() map[string]string {
+	cities := make(map[string]string, len(t))
+	for name, a := range t {
+		cities[name] = a.City
 	}
 
-	return destCity
+	return cities
 }
 
-// DestroyAliens removes the passed aliens from the Tracker as they were horribly destroyed by their enemies.
-func (t Tracker) DestroyAliens(aliens []string) {
-	for _, a := range aliens {
-		delete(t, a)
+// Occupancy returns the aliens in the Tracker at every city. Looking up the aliens at a city in the returned Occupancy
+// doesn't go through the whole Tracker, so it should be built once and then used as the View of many strategy moves.
+func (t Tracker) Occupancy() Occupancy {
+	occupancy := Occupancy{}
+	for _, name := range t.Names() {
+		city := t[name].City
+		occupancy[city] = append(occupancy[city], name)
 	}
+
+	return occupancy
 }
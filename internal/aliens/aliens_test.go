@@ -1,7 +1,7 @@
 package aliens
 
 import (
-	"strings"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,7 +45,7 @@ func Test_New(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			tracker, err := NewTracker(tc.numAliens, tc.world)
+			tracker, err := NewTracker(tc.numAliens, tc.world, nil, rand.New(rand.NewSource(1)))
 
 			if tc.expectsError {
 				assert.Error(t, err)
@@ -57,51 +57,61 @@ func Test_New(t *testing.T) {
 	}
 }
 
-func Test_randomizeCities(t *testing.T) {
+func Test_New_sameSeedSameTracker(t *testing.T) {
+	world := worldmap.World{
+		"Foo":   worldmap.Roads{},
+		"Bar":   worldmap.Roads{},
+		"Baz":   worldmap.Roads{},
+		"Qu-ux": worldmap.Roads{},
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		tracker1, err := NewTracker(2, world, nil, rand.New(rand.NewSource(seed)))
+		assert.Nil(t, err)
+
+		tracker2, err := NewTracker(2, world, nil, rand.New(rand.NewSource(seed)))
+		assert.Nil(t, err)
+
+		assert.Equal(t, tracker1, tracker2)
+	}
+}
+
+func Test_New_namer(t *testing.T) {
 	world := worldmap.World{
 		"Foo": worldmap.Roads{},
 		"Bar": worldmap.Roads{},
 		"Baz": worldmap.Roads{},
 	}
 
-	resultCounts := map[string]int{
-		"FooBarBaz": 0,
-		"FooBazBar": 0,
-		"BarFooBaz": 0,
-		"BarBazFoo": 0,
-		"BazFooBar": 0,
-		"BazBarFoo": 0,
-	}
+	tracker, err := NewTracker(3, world, SequentialNames{}, rand.New(rand.NewSource(1)))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"alien-0001", "alien-0002", "alien-0003"}, tracker.Names())
 
-	// since the results from the function are random, we'll call it a given number of times and collect results.
-	// We will then check those results for statistical randomness
-	numIterations := 2000
-	for i := 0; i < numIterations; i++ {
-		randCitites := randomizeCities(world)
-		resultCounts[strings.Join(randCitites, "")]++
-	}
+	// there aren't enough names
+	_, err = NewTracker(3, world, NameList{"Atna", "Ishae"}, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
 
-	// assert results are selected uniformly
-	assert.Condition(t, func() bool {
-		// allow 15% deviation
-		even := numIterations / len(resultCounts)
-		delta := even * 15 / 100
-		lowThreshold := even - delta
-		highThreshold := even + delta
+	// names aren't unique
+	_, err = NewTracker(3, world, duplicateNames{}, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+}
 
-		for _, count := range resultCounts {
-			if count < lowThreshold || count > highThreshold {
-				return false
-			}
-		}
+// duplicateNames is a Namer that breaks the contract by giving every alien the same name.
+type duplicateNames struct{}
 
-		return true
-	})
+func (duplicateNames) Names(numAliens int, _ *rand.Rand) ([]string, error) {
+	names := make([]string, numAliens)
+	for i := range names {
+		names[i] = "Atna"
+	}
+
+	return names, nil
 }
 
 func Test_randomAlienName(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
 	for i := 0; i < 1000; i++ {
-		name := randomAlienName()
+		name := randomAlienName(rng)
 
 		// is between 4 and 8 characters long
 		assert.True(t, len(name) >= 4 && len(name) <= 8)
@@ -128,133 +138,24 @@ func Test_randomAlienName(t *testing.T) {
 	}
 }
 
-func Test_MoveRandomly(t *testing.T) {
-	tracker := Tracker{
-		"alien 0": "Foo",
-		"alien 1": "Bar",
-		"alien 2": "Baz",
-	}
-
-	world := worldmap.World{
-		"Foo": worldmap.Roads{
-			worldmap.Direction_North: "Bar",
-			worldmap.Direction_West:  "Baz",
-		},
-		"Bar": worldmap.Roads{
-			worldmap.Direction_South: "Foo",
-		},
-		"Baz": worldmap.Roads{
-			worldmap.Direction_East: "Foo",
-		},
-	}
-
-	visitedCities := tracker.MoveRandomly(world)
-
-	// alien 0 can go to Bar or Baz, while aliens 1 and 2 can only go to Foo
-	assert.Condition(t, func() bool {
-		alien0Dest := tracker["alien 0"]
-		return alien0Dest == "Bar" || alien0Dest == "Baz"
-	})
-	assert.Equal(t, "Foo", tracker["alien 1"])
-	assert.Equal(t, "Foo", tracker["alien 2"])
-
-	_, okBar := visitedCities["Bar"]
-	_, okBaz := visitedCities["Baz"]
-	assert.Condition(t, func() bool {
-		return okBar || okBaz
-	})
-
-	if okBar {
-		assert.Equal(t, []string{"alien 0"}, visitedCities["Bar"])
-	}
-	if okBaz {
-		assert.Equal(t, []string{"alien 0"}, visitedCities["Baz"])
-	}
+func Test_Alien_WithDefaults(t *testing.T) {
+	assert.Equal(t, Alien{City: "Foo", Health: 1, Strength: 1, Speed: 1}, Alien{City: "Foo"}.WithDefaults())
 
-	assert.Contains(t, visitedCities, "Foo")
-	assert.Contains(t, visitedCities["Foo"], "alien 1")
-	assert.Contains(t, visitedCities["Foo"], "alien 2")
-	assert.NotContains(t, visitedCities["Foo"], "alien 0")
+	alien := Alien{City: "Foo", Health: 3, Strength: 2, Speed: 4, Moves: 7}
+	assert.Equal(t, alien, alien.WithDefaults())
 }
 
-func Test_pickRandomDestination(t *testing.T) {
-	roads := worldmap.Roads{
-		worldmap.Direction_East:  "Foo",
-		worldmap.Direction_North: "Bar",
-		worldmap.Direction_South: "Baz",
-		worldmap.Direction_West:  "Qu-ux",
-	}
-
-	resultCounts := map[string]int{
-		"Foo":   0,
-		"Bar":   0,
-		"Baz":   0,
-		"Qu-ux": 0,
-	}
-
-	// since the results from the function are random, we will call it a given number of times and collect results.
-	// We will then check those results for statistical randomness
-	numIterations := 2000
-	for i := 0; i < numIterations; i++ {
-		dest := pickRandomDestination(roads)
-		resultCounts[dest]++
-	}
+func Test_Tracker_Cities(t *testing.T) {
+	tracker := Tracker{"alien 0": {City: "Foo", Health: 3}, "alien 1": {City: "Bar"}}
 
-	// assert that roads are selected uniformly (around 2000/4 or 500 times each)
-	assert.Condition(t, func() bool {
-		// allow 15% deviation
-		even := numIterations / len(resultCounts)
-		delta := even * 15 / 100
-		lowThreshold := even - delta
-		highThreshold := even + delta
-
-		for _, count := range resultCounts {
-			if count < lowThreshold || count > highThreshold {
-				return false
-			}
-		}
-
-		return true
-	})
+	assert.Equal(t, map[string]string{"alien 0": "Foo", "alien 1": "Bar"}, tracker.Cities())
 }
 
-func Test_DestroyAliens(t *testing.T) {
-	testCases := map[string]struct {
-		tracker         Tracker
-		aliensToDestroy []string
-		expectedTracker Tracker
-	}{
-		"happy path": {
-			tracker: Tracker{
-				"alien 0": "Foo",
-				"alien 1": "Bar",
-				"alien 2": "Baz",
-				"alien 3": "Qu-ux",
-			},
-			aliensToDestroy: []string{"alien 1", "alien 3"},
-			expectedTracker: Tracker{
-				"alien 0": "Foo",
-				"alien 2": "Baz",
-			},
-		},
-		"destroying a non-existant alien is a no-op": {
-			tracker: Tracker{
-				"alien 0": "Foo",
-				"alien 1": "Bar",
-			},
-			aliensToDestroy: []string{"alien 2", "alien 3"},
-			expectedTracker: Tracker{
-				"alien 0": "Foo",
-				"alien 1": "Bar",
-			},
-		},
-	}
-
-	for name, tc := range testCases {
-		t.Run(name, func(t *testing.T) {
-			tc.tracker.DestroyAliens(tc.aliensToDestroy)
+func Test_Tracker_Occupancy(t *testing.T) {
+	tracker := Tracker{"alien 2": {City: "Foo"}, "alien 0": {City: "Foo"}, "alien 1": {City: "Bar"}}
+	occupancy := tracker.Occupancy()
 
-			assert.Equal(t, tc.expectedTracker, tc.tracker)
-		})
-	}
+	assert.Equal(t, []string{"alien 0", "alien 2"}, occupancy.AliensAt("Foo"))
+	assert.Equal(t, []string{"alien 1"}, occupancy.AliensAt("Bar"))
+	assert.Empty(t, occupancy.AliensAt("Xen"))
 }
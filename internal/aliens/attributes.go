@@ -0,0 +1,90 @@
+package aliens
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Distribution describes how the value of an attribute is chosen for every alien: it is drawn uniformly at random
+// between Min and Max, both inclusive. The zero Distribution leaves attributes unset.
+type Distribution struct {
+	Min int
+	Max int
+}
+
+// ParseDistribution parses a distribution given either as a single value, like "3", or as a range of values, like
+// "1-5". Values must be positive integers.
+func ParseDistribution(s string) (Distribution, error) {
+	minStr, maxStr, isRange := strings.Cut(s, "-")
+	if !isRange {
+		maxStr = minStr
+	}
+
+	min, err := strconv.Atoi(minStr)
+	if err != nil || min < 1 {
+		return Distribution{}, fmt.Errorf("%q is not a positive integer", minStr)
+	}
+
+	max, err := strconv.Atoi(maxStr)
+	if err != nil || max < 1 {
+		return Distribution{}, fmt.Errorf("%q is not a positive integer", maxStr)
+	}
+
+	if max < min {
+		return Distribution{}, fmt.Errorf("the range %s is empty", s)
+	}
+
+	return Distribution{Min: min, Max: max}, nil
+}
+
+// Sample draws a value from d using rng. Distributions of a single value don't use rng at all.
+func (d Distribution) Sample(rng *rand.Rand) int {
+	if d.Min == d.Max {
+		return d.Min
+	}
+
+	return d.Min + rng.Intn(d.Max-d.Min+1)
+}
+
+// Attributes holds the distributions attributes of aliens are drawn from.
+type Attributes struct {
+	Health   Distribution
+	Strength Distribution
+	Speed    Distribution
+}
+
+// Equip sets the attributes of the aliens in the Tracker that are still unset, drawing them from the distributions in
+// attrs using rng. Aliens are equipped in alphabetical order, so the result only depends on the state of rng.
+func (t Tracker) Equip(attrs Attributes, rng *rand.Rand) {
+	for _, name := range t.Names() {
+		alien := t[name]
+		equip(&alien.Health, attrs.Health, rng)
+		equip(&alien.Strength, attrs.Strength, rng)
+		equip(&alien.Speed, attrs.Speed, rng)
+		t[name] = alien
+	}
+}
+
+// equip sets attribute, if it is still unset, to a value drawn from d using rng.
+func equip(attribute *int, d Distribution, rng *rand.Rand) {
+	if *attribute == 0 && d != (Distribution{}) {
+		*attribute = d.Sample(rng)
+	}
+}
+
+// attribute returns a pointer to the attribute of a with the given key, as used in placement files, or an error if
+// there is no such attribute.
+func (a *Alien) attribute(key string) (*int, error) {
+	switch key {
+	case "health":
+		return &a.Health, nil
+	case "strength":
+		return &a.Strength, nil
+	case "speed":
+		return &a.Speed, nil
+	default:
+		return nil, fmt.Errorf("unknown attribute %q", key)
+	}
+}
@@ -0,0 +1,70 @@
+package aliens
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseDistribution(t *testing.T) {
+	testCases := map[string]Distribution{
+		"3":   {Min: 3, Max: 3},
+		"1-5": {Min: 1, Max: 5},
+		"2-2": {Min: 2, Max: 2},
+	}
+	for s, expected := range testCases {
+		d, err := ParseDistribution(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, d, s)
+	}
+
+	for _, s := range []string{"", "0", "-1", "5-1", "a-b", "1-", "1-2-3"} {
+		_, err := ParseDistribution(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func Test_Distribution_Sample(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	seen := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		v := Distribution{Min: 2, Max: 4}.Sample(rng)
+		assert.GreaterOrEqual(t, v, 2)
+		assert.LessOrEqual(t, v, 4)
+		seen[v] = true
+	}
+	assert.Len(t, seen, 3)
+
+	// distributions of a single value leave rng untouched
+	rng, untouched := rand.New(rand.NewSource(2)), rand.New(rand.NewSource(2))
+	assert.Equal(t, 7, Distribution{Min: 7, Max: 7}.Sample(rng))
+	assert.Equal(t, untouched.Int63(), rng.Int63())
+}
+
+func Test_Equip(t *testing.T) {
+	tracker := Tracker{
+		"alien 0": {City: "Foo"},
+		"alien 1": {City: "Bar", Health: 9},
+	}
+
+	tracker.Equip(Attributes{Health: Distribution{Min: 3, Max: 3}, Speed: Distribution{Min: 2, Max: 2}}, rand.New(rand.NewSource(1)))
+
+	// attributes already set, and the ones with no distribution, are left alone
+	assert.Equal(t, Tracker{
+		"alien 0": {City: "Foo", Health: 3, Speed: 2},
+		"alien 1": {City: "Bar", Health: 9, Speed: 2},
+	}, tracker)
+}
+
+func Test_Alien_attribute(t *testing.T) {
+	alien := Alien{Health: 1, Strength: 2, Speed: 3}
+	for key, expected := range map[string]int{"health": 1, "strength": 2, "speed": 3} {
+		attribute, err := alien.attribute(key)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, *attribute)
+	}
+
+	_, err := alien.attribute("colour")
+	assert.Error(t, err)
+}
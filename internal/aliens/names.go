@@ -0,0 +1,143 @@
+package aliens
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Namer names the aliens of an invasion.
+type Namer interface {
+	// Names returns numAliens distinct names, or an error if there aren't as many. Every random decision must be
+	// taken using rng.
+	Names(numAliens int, rng *rand.Rand) ([]string, error)
+}
+
+// Namer names accepted by NewNamer.
+const (
+	NamerName_Random     = "random"
+	NamerName_Sequential = "sequential"
+)
+
+// NamerNames lists the names of the built-in namers.
+var NamerNames = []string{NamerName_Random, NamerName_Sequential}
+
+// NewNamer creates a new built-in Namer given its name. Names loaded from a file are given by ReadNamesFile instead.
+func NewNamer(name string) (Namer, error) {
+	switch name {
+	case NamerName_Random:
+		return RandomNames{}, nil
+	case NamerName_Sequential:
+		return SequentialNames{}, nil
+	default:
+		return nil, fmt.Errorf("unknown naming scheme %q, it must be one of %s", name, strings.Join(NamerNames, ", "))
+	}
+}
+
+// maxNameAttempts is the number of random names RandomNames draws for an alien before giving up on finding one that
+// is not taken yet.
+const maxNameAttempts = 100
+
+// RandomNames is a Namer that gives aliens random alien-like names. It is the Namer used by NewTracker by default.
+type RandomNames struct{}
+
+// Names implements the Namer interface. Names that are already taken are drawn again.
+func (RandomNames) Names(numAliens int, rng *rand.Rand) ([]string, error) {
+	names := make([]string, 0, numAliens)
+	taken := make(map[string]bool, numAliens)
+	for len(names) < numAliens {
+		name := randomAlienName(rng)
+		for attempts := 1; taken[name]; attempts++ {
+			if attempts == maxNameAttempts {
+				return nil, fmt.Errorf("could only find %d different names for %d aliens", len(names), numAliens)
+			}
+			name = randomAlienName(rng)
+		}
+
+		taken[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// SequentialNames is a Namer that numbers aliens from 1 onwards, like alien-0001. Numbers are padded with zeros so
+// that alphabetical order matches the order in which aliens are named.
+type SequentialNames struct{}
+
+// Names implements the Namer interface.
+func (SequentialNames) Names(numAliens int, _ *rand.Rand) ([]string, error) {
+	width := len(strconv.Itoa(numAliens))
+	if width < 4 {
+		width = 4
+	}
+
+	names := make([]string, numAliens)
+	for i := range names {
+		names[i] = fmt.Sprintf("alien-%0*d", width, i+1)
+	}
+
+	return names, nil
+}
+
+// NameList is a Namer that gives aliens the names in the list, in order.
+type NameList []string
+
+// Names implements the Namer interface.
+func (l NameList) Names(numAliens int, _ *rand.Rand) ([]string, error) {
+	if numAliens > len(l) {
+		return nil, fmt.Errorf("there are only %d names for %d aliens", len(l), numAliens)
+	}
+
+	return l[:numAliens], nil
+}
+
+// ReadNamesFile parses the names file at path.
+// Names files have a name per line. Names can't contain spaces, and can only be listed once. Lines starting with '#'
+// are comments, and blank lines are ignored.
+func ReadNamesFile(path string) (NameList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseNames(file)
+}
+
+// ParseNames parses a names file read from r. See ReadNamesFile for a description of the format.
+func ParseNames(r io.Reader) (NameList, error) {
+	names := NameList{}
+	listed := map[string]int{}
+	lineNum := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNum++
+
+		name := strings.TrimSpace(line)
+		if name == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("malformed name at line %d: %s", lineNum, line)
+		}
+
+		if first, ok := listed[name]; ok {
+			return nil, fmt.Errorf("name %s at line %d already listed at line %d", name, lineNum, first)
+		}
+		listed[name] = lineNum
+		names = append(names, name)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
@@ -0,0 +1,86 @@
+package aliens
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewNamer(t *testing.T) {
+	for _, name := range NamerNames {
+		namer, err := NewNamer(name)
+		assert.NoError(t, err)
+		assert.NotNil(t, namer)
+	}
+
+	_, err := NewNamer("alphabetical")
+	assert.Error(t, err)
+}
+
+func Test_RandomNames(t *testing.T) {
+	names, err := RandomNames{}.Names(10_000, rand.New(rand.NewSource(1)))
+	assert.NoError(t, err)
+	assert.Len(t, names, 10_000)
+
+	unique := map[string]bool{}
+	for _, name := range names {
+		unique[name] = true
+	}
+	assert.Len(t, unique, 10_000)
+
+	// the same seed always produces the same names
+	again, err := RandomNames{}.Names(10_000, rand.New(rand.NewSource(1)))
+	assert.NoError(t, err)
+	assert.Equal(t, names, again)
+}
+
+func Test_SequentialNames(t *testing.T) {
+	names, err := SequentialNames{}.Names(3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alien-0001", "alien-0002", "alien-0003"}, names)
+
+	names, err = SequentialNames{}.Names(12_345, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "alien-00001", names[0])
+	assert.Equal(t, "alien-12345", names[12_344])
+}
+
+func Test_NameList(t *testing.T) {
+	list := NameList{"Atna", "Ishae", "Oru"}
+
+	names, err := list.Names(2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Atna", "Ishae"}, names)
+
+	_, err = list.Names(4, nil)
+	assert.Error(t, err)
+}
+
+func Test_ParseNames(t *testing.T) {
+	contents := "" +
+		"# the usual suspects\n" +
+		"Atna\n" +
+		"\n" +
+		"  Ishae  \n" +
+		"Oru\n"
+
+	names, err := ParseNames(strings.NewReader(contents))
+	assert.Nil(t, err)
+	assert.Equal(t, NameList{"Atna", "Ishae", "Oru"}, names)
+}
+
+func Test_ParseNames_errors(t *testing.T) {
+	testCases := map[string]string{
+		"name listed twice": "Atna\nIshae\nAtna",
+		"name with spaces":  "Atna Ishae",
+	}
+
+	for name, contents := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseNames(strings.NewReader(contents))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package aliens
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Placement describes where every alien of an invasion starts and how it behaves, as declared in a placement file.
+type Placement struct {
+	// Tracker holds the city every alien starts at, and the attributes it declares. Attributes that are not declared
+	// are left unset.
+	Tracker Tracker
+	// Order lists the aliens in the order they are declared in the placement file.
+	Order []string
+	// Strategies holds the strategy of the aliens that declare one.
+	Strategies map[string]Strategy
+}
+
+// ReadPlacementFile parses the placement file at path.
+// Placement files have an alien per line, with the format '<alien_name> <city_name> [<key>=<value>]...', where the
+// optional key-value pairs set the attributes of the alien. Keys can be "strategy", whose value is the name of one of
+// the strategies accepted by NewStrategy, and "health", "strength" and "speed", whose values are positive integers.
+// Lines starting with '#' are comments, and blank lines are ignored. Every alien can only be declared once, and no two
+// aliens can start in the same city.
+func ReadPlacementFile(path string) (*Placement, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParsePlacement(file)
+}
+
+// ParsePlacement parses a placement file read from r. See ReadPlacementFile for a description of the format.
+func ParsePlacement(r io.Reader) (*Placement, error) {
+	placement := &Placement{Tracker: Tracker{}, Order: []string{}, Strategies: map[string]Strategy{}}
+	occupied := map[string]string{}
+	lineNum := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNum++
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, " ")
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("malformed alien declaration at line %d: %s", lineNum, line)
+		}
+
+		name, city := parts[0], parts[1]
+		if _, declared := placement.Tracker[name]; declared {
+			return nil, fmt.Errorf("alien %s declared again at line %d", name, lineNum)
+		}
+
+		if other, ok := occupied[city]; ok {
+			return nil, fmt.Errorf("alien %s placed at line %d in %s, where %s already is", name, lineNum, city, other)
+		}
+
+		alien := Alien{City: city}
+		for _, attr := range parts[2:] {
+			key, value, ok := strings.Cut(attr, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("malformed attribute at line %d: %s", lineNum, attr)
+			}
+
+			if key == "strategy" {
+				strategy, err := NewStrategy(value)
+				if err != nil {
+					return nil, fmt.Errorf("bad strategy at line %d: %w", lineNum, err)
+				}
+				placement.Strategies[name] = strategy
+				continue
+			}
+
+			attribute, err := alien.attribute(key)
+			if err != nil {
+				return nil, fmt.Errorf("unknown attribute at line %d: %s", lineNum, key)
+			}
+
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad %s at line %d: %s is not a positive integer", key, lineNum, value)
+			}
+			*attribute = n
+		}
+
+		placement.Tracker[name] = alien
+		placement.Order = append(placement.Order, name)
+		occupied[city] = name
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return placement, nil
+}
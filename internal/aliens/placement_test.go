@@ -0,0 +1,52 @@
+package aliens
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParsePlacement(t *testing.T) {
+	contents := "" +
+		"# the hunt is on\n" +
+		"Atna Foo strategy=hunter\n" +
+		"\n" +
+		"Ishae Bar health=3 speed=2\n" +
+		"Oru Baz strategy=lazy strength=4\n"
+
+	placement, err := ParsePlacement(strings.NewReader(contents))
+	assert.Nil(t, err)
+
+	assert.Equal(t, Tracker{
+		"Atna":  {City: "Foo"},
+		"Ishae": {City: "Bar", Health: 3, Speed: 2},
+		"Oru":   {City: "Baz", Strength: 4},
+	}, placement.Tracker)
+	assert.Equal(t, []string{"Atna", "Ishae", "Oru"}, placement.Order)
+	assert.Len(t, placement.Strategies, 2)
+	assert.IsType(t, Hunter{}, placement.Strategies["Atna"])
+	assert.IsType(t, Lazy{}, placement.Strategies["Oru"])
+}
+
+func Test_ParsePlacement_errors(t *testing.T) {
+	testCases := map[string]string{
+		"missing city":         "Atna",
+		"alien declared twice": "Atna Foo\nAtna Bar",
+		"two aliens in a city": "Atna Foo\nIshae Foo",
+		"unknown strategy":     "Atna Foo strategy=teleport",
+		"unknown attribute":    "Atna Foo colour=green",
+		"malformed attribute":  "Atna Foo strategy",
+		"double space":         "Atna  Foo",
+		"zero health":          "Atna Foo health=0",
+		"non-numeric speed":    "Atna Foo speed=fast",
+	}
+
+	for name, contents := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePlacement(strings.NewReader(contents))
+
+			assert.NotNil(t, err)
+		})
+	}
+}
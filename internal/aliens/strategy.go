@@ -0,0 +1,263 @@
+package aliens
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// RoadMap is a read-only view of the roads of a world. worldmap.World and every worldmap.Terrain are RoadMaps.
+type RoadMap interface {
+	// Roads returns the roads that leave city. The returned Roads must not be modified.
+	Roads(city string) worldmap.Roads
+}
+
+// View is a read-only view of where aliens are.
+type View interface {
+	// AliensAt returns the names of the aliens at city, in alphabetical order.
+	AliensAt(city string) []string
+}
+
+// Occupancy is a View that maps every city to the aliens in it, in alphabetical order. Tracker.Occupancy builds the
+// Occupancy of the aliens in a Tracker.
+type Occupancy map[string][]string
+
+// AliensAt implements the View interface.
+func (o Occupancy) AliensAt(city string) []string {
+	return o[city]
+}
+
+// Strategy decides where aliens move to. Aliens move simultaneously: world and others show what the world looks like
+// at the start of the iteration, before any alien moves.
+type Strategy interface {
+	// Move returns the direction of the road alien takes from city, the city it is at, or an empty Direction if it
+	// stays where it is. Every random decision must be taken using rng.
+	Move(alien, city string, world RoadMap, others View, rng *rand.Rand) worldmap.Direction
+}
+
+// Strategy names accepted by NewStrategy.
+const (
+	StrategyName_Uniform         = "uniform"
+	StrategyName_NonBacktracking = "non-backtracking"
+	StrategyName_Lazy            = "lazy"
+	StrategyName_Hunter          = "hunter"
+	StrategyName_Evader          = "evader"
+)
+
+// StrategyNames lists the names of the built-in strategies.
+var StrategyNames = []string{
+	StrategyName_Uniform,
+	StrategyName_NonBacktracking,
+	StrategyName_Lazy,
+	StrategyName_Hunter,
+	StrategyName_Evader,
+}
+
+// defaultSightRange is how far, in roads, hunters and evaders created by NewStrategy look for other aliens.
+const defaultSightRange = 10
+
+// NewStrategy creates a new built-in Strategy given its name, using sensible defaults for its parameters.
+func NewStrategy(name string) (Strategy, error) {
+	switch name {
+	case StrategyName_Uniform:
+		return Uniform{}, nil
+	case StrategyName_NonBacktracking:
+		return NewNonBacktracking(), nil
+	case StrategyName_Lazy:
+		return Lazy{StayProbability: 0.5}, nil
+	case StrategyName_Hunter:
+		return Hunter{SightRange: defaultSightRange}, nil
+	case StrategyName_Evader:
+		return Evader{SightRange: defaultSightRange}, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q, it must be one of %s", name, strings.Join(StrategyNames, ", "))
+	}
+}
+
+// Uniform is a Strategy where aliens take any of the roads available to them with the same probability.
+type Uniform struct{}
+
+// Move implements the Strategy interface.
+func (Uniform) Move(_, city string, world RoadMap, _ View, rng *rand.Rand) worldmap.Direction {
+	return pickRandomDirection(world.Roads(city), rng)
+}
+
+// NonBacktracking is a Strategy where aliens take any road at random, except the one leading back to the city they
+// come from, unless it is the only one available.
+type NonBacktracking struct {
+	previous map[string]string
+}
+
+// NewNonBacktracking creates a new NonBacktracking strategy.
+func NewNonBacktracking() *NonBacktracking {
+	return &NonBacktracking{previous: map[string]string{}}
+}
+
+// Move implements the Strategy interface.
+func (s *NonBacktracking) Move(alien, city string, world RoadMap, _ View, rng *rand.Rand) worldmap.Direction {
+	roads := world.Roads(city)
+	forward := worldmap.Roads{}
+	for dir, dest := range roads {
+		if dest != s.previous[alien] {
+			forward[dir] = dest
+		}
+	}
+	if len(forward) == 0 {
+		forward = roads
+	}
+
+	dir := pickRandomDirection(forward, rng)
+	if dir != "" {
+		s.previous[alien] = city
+	}
+
+	return dir
+}
+
+// Lazy is a Strategy where aliens stay where they are with probability StayProbability, and take any road at random
+// otherwise.
+type Lazy struct {
+	StayProbability float64
+}
+
+// Move implements the Strategy interface.
+func (s Lazy) Move(_, city string, world RoadMap, _ View, rng *rand.Rand) worldmap.Direction {
+	if rng.Float64() < s.StayProbability {
+		return ""
+	}
+
+	return pickRandomDirection(world.Roads(city), rng)
+}
+
+// Hunter is a Strategy where aliens take the first road of the shortest path to the nearest alien they can see, up to
+// SightRange roads away. Aliens that can't see any other alien take any road at random.
+type Hunter struct {
+	SightRange int
+}
+
+// Move implements the Strategy interface.
+func (s Hunter) Move(alien, city string, world RoadMap, others View, rng *rand.Rand) worldmap.Direction {
+	if dir, _ := nearestAlien(alien, city, world, others, s.SightRange); dir != "" {
+		return dir
+	}
+
+	return pickRandomDirection(world.Roads(city), rng)
+}
+
+// Evader is a Strategy where aliens take the road that leads them farthest from the nearest alien they can see, up to
+// SightRange roads away, choosing at random between equally good roads. Aliens that can't see any other alien take any
+// road at random.
+type Evader struct {
+	SightRange int
+}
+
+// Move implements the Strategy interface.
+func (s Evader) Move(alien, city string, world RoadMap, others View, rng *rand.Rand) worldmap.Direction {
+	roads := world.Roads(city)
+	best, bestDistance := worldmap.Roads{}, -1
+	for _, dir := range worldmap.Directions {
+		dest, ok := roads[dir]
+		if !ok {
+			continue
+		}
+
+		// the alien would be one road farther from the aliens seen from dest, so look one road less far from there
+		distance := s.SightRange + 1
+		if len(otherAliens(alien, others.AliensAt(dest))) > 0 {
+			distance = 0
+		} else if _, d := nearestAlien(alien, dest, world, others, s.SightRange-1); d > 0 {
+			distance = d
+		}
+
+		switch {
+		case distance > bestDistance:
+			best, bestDistance = worldmap.Roads{dir: dest}, distance
+		case distance == bestDistance:
+			best[dir] = dest
+		}
+	}
+
+	return pickRandomDirection(best, rng)
+}
+
+// nearestAlien looks for the nearest city to origin with aliens other than alien in it, up to sightRange roads away. It
+// returns the direction of the first road of the shortest path to that city and its distance to origin, or an empty
+// Direction and 0 if there is none. Roads are explored in the order given by worldmap.Directions, so the result is
+// deterministic.
+func nearestAlien(alien, origin string, world RoadMap, others View, sightRange int) (worldmap.Direction, int) {
+	type step struct {
+		city     string
+		first    worldmap.Direction
+		distance int
+	}
+
+	visited := map[string]bool{origin: true}
+	queue := []step{{city: origin}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.distance >= sightRange {
+			continue
+		}
+
+		roads := world.Roads(current.city)
+		for _, dir := range worldmap.Directions {
+			dest, ok := roads[dir]
+			if !ok || visited[dest] {
+				continue
+			}
+			visited[dest] = true
+
+			next := step{city: dest, first: current.first, distance: current.distance + 1}
+			if next.first == "" {
+				next.first = dir
+			}
+
+			if len(otherAliens(alien, others.AliensAt(dest))) > 0 {
+				return next.first, next.distance
+			}
+
+			queue = append(queue, next)
+		}
+	}
+
+	return "", 0
+}
+
+// otherAliens returns the aliens in aliens other than alien.
+func otherAliens(alien string, aliens []string) []string {
+	others := make([]string, 0, len(aliens))
+	for _, a := range aliens {
+		if a != alien {
+			others = append(others, a)
+		}
+	}
+
+	return others
+}
+
+// pickRandomDirection picks the direction of a random road from roads, enumerating them in the order given by
+// worldmap.Directions, or returns an empty Direction if there are no roads.
+func pickRandomDirection(roads worldmap.Roads, rng *rand.Rand) worldmap.Direction {
+	if len(roads) == 0 {
+		return ""
+	}
+
+	randIdx := rng.Intn(len(roads))
+	i := 0
+	for _, dir := range worldmap.Directions {
+		if _, ok := roads[dir]; !ok {
+			continue
+		}
+
+		if i == randIdx {
+			return dir
+		}
+
+		i++
+	}
+
+	return ""
+}
@@ -0,0 +1,165 @@
+package aliens
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// lineWorld returns a world where cities are in a line, from west to east.
+func lineWorld(cities ...string) worldmap.World {
+	world := worldmap.World{}
+	for i, c := range cities {
+		world[c] = worldmap.Roads{}
+		if i > 0 {
+			world[c][worldmap.Direction_West] = cities[i-1]
+			world[cities[i-1]][worldmap.Direction_East] = c
+		}
+	}
+
+	return world
+}
+
+func Test_NewStrategy(t *testing.T) {
+	for _, name := range StrategyNames {
+		strategy, err := NewStrategy(name)
+		assert.Nil(t, err)
+		assert.NotNil(t, strategy)
+	}
+
+	_, err := NewStrategy("teleport")
+	assert.NotNil(t, err)
+}
+
+func Test_Uniform(t *testing.T) {
+	world := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_North: "Bar", worldmap.Direction_West: "Baz"},
+		"Bar": worldmap.Roads{worldmap.Direction_South: "Foo"},
+		"Baz": worldmap.Roads{worldmap.Direction_East: "Foo"},
+		"Xen": worldmap.Roads{},
+	}
+
+	// same decisions as pickRandomDirection
+	rng1, rng2 := rand.New(rand.NewSource(1)), rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		dir := Uniform{}.Move("alien 0", "Foo", world, Occupancy{}, rng1)
+		assert.Equal(t, pickRandomDirection(world["Foo"], rng2), dir)
+	}
+
+	assert.Equal(t, worldmap.Direction(""), Uniform{}.Move("alien 0", "Xen", world, Occupancy{}, rng1))
+}
+
+func Test_NonBacktracking(t *testing.T) {
+	world := lineWorld("A", "B", "C", "D")
+	strategy := NewNonBacktracking()
+	rng := rand.New(rand.NewSource(1))
+
+	// once it starts moving east, it never turns back until the end of the line
+	city := "B"
+	for city != "D" {
+		dir := strategy.Move("alien 0", city, world, Occupancy{}, rng)
+		if city == "B" && dir == worldmap.Direction_West {
+			strategy = NewNonBacktracking()
+			continue
+		}
+
+		assert.Equal(t, worldmap.Direction_East, dir)
+		city = world[city][dir]
+	}
+
+	// at a dead end, going back is the only way
+	assert.Equal(t, worldmap.Direction_West, strategy.Move("alien 0", "D", world, Occupancy{}, rng))
+}
+
+func Test_Lazy(t *testing.T) {
+	world := lineWorld("A", "B")
+	rng := rand.New(rand.NewSource(1))
+
+	assert.Equal(t, worldmap.Direction(""), Lazy{StayProbability: 1}.Move("alien 0", "A", world, Occupancy{}, rng))
+	assert.Equal(t, worldmap.Direction_East, Lazy{StayProbability: 0}.Move("alien 0", "A", world, Occupancy{}, rng))
+
+	stays := 0
+	for i := 0; i < 1000; i++ {
+		if (Lazy{StayProbability: 0.3}).Move("alien 0", "A", world, Occupancy{}, rng) == "" {
+			stays++
+		}
+	}
+	assert.InDelta(t, 300, stays, 50)
+}
+
+func Test_Hunter(t *testing.T) {
+	world := lineWorld("A", "B", "C", "D", "E")
+	rng := rand.New(rand.NewSource(1))
+
+	others := Tracker{"hunter": {City: "B"}, "prey": {City: "E"}}.Occupancy()
+	assert.Equal(t, worldmap.Direction_East, Hunter{SightRange: 3}.Move("hunter", "B", world, others, rng))
+
+	// the prey is out of sight, so the hunter moves at random
+	moves := map[worldmap.Direction]bool{}
+	for i := 0; i < 100; i++ {
+		moves[Hunter{SightRange: 2}.Move("hunter", "B", world, others, rng)] = true
+	}
+	assert.Len(t, moves, 2)
+}
+
+func Test_Evader(t *testing.T) {
+	world := lineWorld("A", "B", "C", "D", "E")
+	rng := rand.New(rand.NewSource(1))
+
+	others := Tracker{"evader": {City: "C"}, "hunter": {City: "E"}}.Occupancy()
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, worldmap.Direction_West, Evader{SightRange: 5}.Move("evader", "C", world, others, rng))
+	}
+
+	// with no one in sight, the evader moves at random
+	others = Tracker{"evader": {City: "B"}, "hunter": {City: "E"}}.Occupancy()
+	moves := map[worldmap.Direction]bool{}
+	for i := 0; i < 100; i++ {
+		moves[Evader{SightRange: 1}.Move("evader", "B", world, others, rng)] = true
+	}
+	assert.Len(t, moves, 2)
+}
+
+func Test_pickRandomDirection(t *testing.T) {
+	roads := worldmap.Roads{
+		worldmap.Direction_East:  "Foo",
+		worldmap.Direction_North: "Bar",
+		worldmap.Direction_South: "Baz",
+		worldmap.Direction_West:  "Qu-ux",
+	}
+
+	resultCounts := map[string]int{
+		"Foo":   0,
+		"Bar":   0,
+		"Baz":   0,
+		"Qu-ux": 0,
+	}
+
+	// since the results from the function are random, we will call it a given number of times and collect results.
+	// We will then check those results for statistical randomness
+	rng := rand.New(rand.NewSource(1))
+	numIterations := 2000
+	for i := 0; i < numIterations; i++ {
+		dir := pickRandomDirection(roads, rng)
+		resultCounts[roads[dir]]++
+	}
+
+	// assert that roads are selected uniformly (around 2000/4 or 500 times each)
+	assert.Condition(t, func() bool {
+		// allow 15% deviation
+		even := numIterations / len(resultCounts)
+		delta := even * 15 / 100
+		lowThreshold := even - delta
+		highThreshold := even + delta
+
+		for _, count := range resultCounts {
+			if count < lowThreshold || count > highThreshold {
+				return false
+			}
+		}
+
+		return true
+	})
+}
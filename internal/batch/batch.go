@@ -0,0 +1,114 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// Config holds the parameters of a batch of simulations.
+type Config struct {
+	// Runs is the number of independent simulations to run.
+	Runs int
+	// Workers is the number of simulations that can run in parallel.
+	Workers int
+	// NumAliens is the number of aliens unleashed in every simulation.
+	NumAliens int
+	// MaxIterations is the maximum number of iterations of every simulation.
+	MaxIterations int
+	// Seed is the master seed the seeds of the individual simulations are derived from.
+	Seed int64
+}
+
+// Outcome is the summary of a single simulation of the batch.
+type Outcome struct {
+	// Seed is the seed used for the simulation. Running a single simulation with the same map, number of aliens and
+	// this seed reproduces it.
+	Seed            int64
+	Iterations      int
+	Reason          simulation.TerminationReason
+	DestroyedCities []simulation.DestroyedCity
+	AliensSurviving int
+}
+
+// Run runs cfg.Runs independent simulations of an invasion of world and aggregates their outcomes. world is never
+// modified: it is converted to a worldmap.Compact once, and every simulation works on its own clone of it.
+//
+// The seed of each simulation is derived from cfg.Seed beforehand and outcomes are aggregated in the order the
+// simulations were defined, so the resulting Stats only depend on the master seed and not on the number of workers
+// or how the runtime schedules them.
+func Run(world worldmap.World, cfg Config) (Stats, error) {
+	if cfg.Runs <= 0 {
+		return Stats{}, errors.New("the number of runs must be greater than 0")
+	}
+
+	if cfg.Workers <= 0 {
+		return Stats{}, errors.New("the number of workers must be greater than 0")
+	}
+
+	if cfg.NumAliens > len(world) {
+		return Stats{}, fmt.Errorf("not enough cities (%d) to place %d aliens", len(world), cfg.NumAliens)
+	}
+
+	master := rand.New(rand.NewSource(cfg.Seed))
+	seeds := make([]int64, cfg.Runs)
+	for i := range seeds {
+		seeds[i] = master.Int63()
+	}
+
+	compact := worldmap.NewCompact(world)
+	outcomes := make([]Outcome, cfg.Runs)
+	errs := make([]error, cfg.Runs)
+
+	runs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range runs {
+				outcomes[i], errs[i] = runOne(compact, cfg, seeds[i])
+			}
+		}()
+	}
+
+	for i := 0; i < cfg.Runs; i++ {
+		runs <- i
+	}
+	close(runs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return Stats{}, fmt.Errorf("run %d (seed %d): %w", i, seeds[i], err)
+		}
+	}
+
+	return aggregate(world, outcomes), nil
+}
+
+// runOne runs a single simulation on a clone of world using the given seed.
+func runOne(world *worldmap.Compact, cfg Config, seed int64) (Outcome, error) {
+	rng := rand.New(rand.NewSource(seed))
+	worldCopy := world.Clone()
+
+	alienTracker, err := aliens.NewTracker(cfg.NumAliens, worldCopy, nil, rng)
+	if err != nil {
+		return Outcome{}, err
+	}
+
+	result := simulation.Run(worldCopy, alienTracker, cfg.MaxIterations, rng, nil, simulation.Options{})
+
+	return Outcome{
+		Seed:            seed,
+		Iterations:      result.Iterations,
+		Reason:          result.Reason,
+		DestroyedCities: result.DestroyedCities,
+		AliensSurviving: len(result.SurvivingAliens),
+	}, nil
+}
@@ -0,0 +1,93 @@
+package batch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// testWorld returns a small world to run simulations on:
+//
+//	Bee --- Bar
+//	         |
+//	Baz --- Foo
+//	         |
+//	        Qu-ux
+func testWorld() worldmap.World {
+	return worldmap.World{
+		"Foo": worldmap.Roads{
+			worldmap.Direction_North: "Bar",
+			worldmap.Direction_West:  "Baz",
+			worldmap.Direction_South: "Qu-ux",
+		},
+		"Bar": worldmap.Roads{
+			worldmap.Direction_South: "Foo",
+			worldmap.Direction_West:  "Bee",
+		},
+		"Baz": worldmap.Roads{
+			worldmap.Direction_East: "Foo",
+		},
+		"Qu-ux": worldmap.Roads{
+			worldmap.Direction_North: "Foo",
+		},
+		"Bee": worldmap.Roads{
+			worldmap.Direction_East: "Bar",
+		},
+	}
+}
+
+func Test_Run(t *testing.T) {
+	world := testWorld()
+
+	stats, err := Run(world, Config{Runs: 200, Workers: 4, NumAliens: 3, MaxIterations: 100, Seed: 1})
+	assert.Nil(t, err)
+
+	// the original world is left untouched
+	assert.Equal(t, testWorld(), world)
+
+	assert.Len(t, stats.Outcomes, 200)
+	assert.Equal(t, 200, stats.CitiesDestroyed.Len())
+	assert.Equal(t, 200, stats.AliensSurviving.Len())
+	assert.Len(t, stats.CityRisks, len(world))
+	for i, r := range stats.CityRisks {
+		assert.Contains(t, world, r.City)
+		assert.True(t, r.Probability >= 0 && r.Probability <= 1)
+		if i > 0 {
+			assert.True(t, stats.CityRisks[i-1].Probability >= r.Probability)
+		}
+	}
+
+	// with 3 aliens, at most one city can be destroyed per run
+	assert.Equal(t, 1, stats.CitiesDestroyed.Max())
+}
+
+func Test_Run_sameSeedSameStatsRegardlessOfWorkers(t *testing.T) {
+	cfg := Config{Runs: 100, NumAliens: 4, MaxIterations: 100, Seed: 42}
+
+	cfg.Workers = 1
+	expected, err := Run(testWorld(), cfg)
+	assert.Nil(t, err)
+
+	for _, workers := range []int{2, 3, 8} {
+		cfg.Workers = workers
+		stats, err := Run(testWorld(), cfg)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, stats)
+	}
+}
+
+func Test_Run_errors(t *testing.T) {
+	testCases := map[string]Config{
+		"no runs":         {Runs: 0, Workers: 1, NumAliens: 1, MaxIterations: 10},
+		"no workers":      {Runs: 1, Workers: 0, NumAliens: 1, MaxIterations: 10},
+		"too many aliens": {Runs: 1, Workers: 1, NumAliens: 6, MaxIterations: 10},
+	}
+
+	for name, cfg := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Run(testWorld(), cfg)
+			assert.Error(t, err)
+		})
+	}
+}
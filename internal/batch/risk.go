@@ -0,0 +1,86 @@
+package batch
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// CityRisk summarizes how likely a city is to be destroyed in an invasion.
+type CityRisk struct {
+	City string
+	// Destructions is the number of simulations in which the city was destroyed.
+	Destructions int
+	// Probability is the empirical probability of the city being destroyed, i.e. the fraction of simulations in which
+	// it was destroyed.
+	Probability float64
+	// MeanIteration is the mean iteration at which the city was destroyed, over the simulations in which it was.
+	// It is 0 if the city was never destroyed.
+	MeanIteration float64
+}
+
+// sortCityRisks sorts risks from the most to the least likely to be destroyed. Cities equally likely to be destroyed
+// are sorted by how early they fall and, finally, alphabetically.
+func sortCityRisks(risks []CityRisk) {
+	sort.Slice(risks, func(i, j int) bool {
+		if risks[i].Probability != risks[j].Probability {
+			return risks[i].Probability > risks[j].Probability
+		}
+
+		if risks[i].MeanIteration != risks[j].MeanIteration {
+			return risks[i].MeanIteration < risks[j].MeanIteration
+		}
+
+		return risks[i].City < risks[j].City
+	})
+}
+
+// WriteCityRiskTable writes risks to out as a human-readable table, in the same order they are given.
+func WriteCityRiskTable(out io.Writer, risks []CityRisk) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "City\tP(destroyed)\tMean iteration")
+	for _, r := range risks {
+		meanIteration := "-"
+		if r.Destructions > 0 {
+			meanIteration = fmt.Sprintf("%.2f", r.MeanIteration)
+		}
+
+		fmt.Fprintf(tw, "%s\t%.3f\t%s\n", r.City, r.Probability, meanIteration)
+	}
+
+	return tw.Flush()
+}
+
+// WriteCityRiskCSV writes risks to out in CSV format, in the same order they are given. The first record is a header
+// with the names of the columns: city, destructions, probability and mean_iteration. The mean iteration is left empty
+// for cities that were never destroyed.
+func WriteCityRiskCSV(out io.Writer, risks []CityRisk) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"city", "destructions", "probability", "mean_iteration"}); err != nil {
+		return err
+	}
+
+	for _, r := range risks {
+		meanIteration := ""
+		if r.Destructions > 0 {
+			meanIteration = strconv.FormatFloat(r.MeanIteration, 'f', -1, 64)
+		}
+
+		record := []string{
+			r.City,
+			strconv.Itoa(r.Destructions),
+			strconv.FormatFloat(r.Probability, 'f', -1, 64),
+			meanIteration,
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,42 @@
+package batch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sortCityRisks(t *testing.T) {
+	risks := []CityRisk{
+		{City: "Baz", Destructions: 0, Probability: 0},
+		{City: "Foo", Destructions: 2, Probability: 0.5, MeanIteration: 6},
+		{City: "Bee", Destructions: 2, Probability: 0.5, MeanIteration: 6},
+		{City: "Qu-ux", Destructions: 0, Probability: 0},
+		{City: "Bar", Destructions: 2, Probability: 0.5, MeanIteration: 2},
+		{City: "Kaa", Destructions: 3, Probability: 0.75, MeanIteration: 10},
+	}
+
+	sortCityRisks(risks)
+
+	cities := []string{}
+	for _, r := range risks {
+		cities = append(cities, r.City)
+	}
+	assert.Equal(t, []string{"Kaa", "Bar", "Bee", "Foo", "Baz", "Qu-ux"}, cities)
+}
+
+func Test_WriteCityRiskCSV(t *testing.T) {
+	risks := []CityRisk{
+		{City: "Bar", Destructions: 3, Probability: 0.75, MeanIteration: 4},
+		{City: "Foo", Destructions: 1, Probability: 0.25, MeanIteration: 2.5},
+		{City: "Baz", Destructions: 0, Probability: 0, MeanIteration: 0},
+	}
+
+	out := &bytes.Buffer{}
+	err := WriteCityRiskCSV(out, risks)
+	assert.Nil(t, err)
+
+	expected := "city,destructions,probability,mean_iteration\nBar,3,0.75,4\nFoo,1,0.25,2.5\nBaz,0,0,\n"
+	assert.Equal(t, expected, out.String())
+}
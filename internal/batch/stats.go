@@ -0,0 +1,179 @@
+package batch
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// Stats aggregates the outcomes of a batch of simulations.
+type Stats struct {
+	// Outcomes holds the outcome of every simulation, in the order they were defined.
+	Outcomes []Outcome
+	// CitiesDestroyed is the distribution of the number of cities destroyed per simulation.
+	CitiesDestroyed Distribution
+	// AliensSurviving is the distribution of the number of aliens alive at the end of each simulation.
+	AliensSurviving Distribution
+	// IterationsToExtinction is the distribution of the number of iterations it took for all aliens to be destroyed.
+	// Only simulations that ended that way are taken into account.
+	IterationsToExtinction Distribution
+	// CityRisks holds the risk of destruction of every city in the world, from the most to the least likely to be
+	// destroyed.
+	CityRisks []CityRisk
+}
+
+// aggregate computes the Stats for the given outcomes of simulations of world.
+func aggregate(world worldmap.World, outcomes []Outcome) Stats {
+	citiesDestroyed := make([]int, 0, len(outcomes))
+	aliensSurviving := make([]int, 0, len(outcomes))
+	iterationsToExtinction := []int{}
+	destructions := make(map[string]int, len(world))
+	destructionIterations := make(map[string]int, len(world))
+
+	for _, o := range outcomes {
+		citiesDestroyed = append(citiesDestroyed, len(o.DestroyedCities))
+		aliensSurviving = append(aliensSurviving, o.AliensSurviving)
+		if o.Reason == simulation.TerminationReason_AllAliensDestroyed {
+			iterationsToExtinction = append(iterationsToExtinction, o.Iterations)
+		}
+
+		for _, d := range o.DestroyedCities {
+			destructions[d.City]++
+			destructionIterations[d.City] += d.Iteration
+		}
+	}
+
+	risks := make([]CityRisk, 0, len(world))
+	for c := range world {
+		risk := CityRisk{
+			City:         c,
+			Destructions: destructions[c],
+			Probability:  float64(destructions[c]) / float64(len(outcomes)),
+		}
+		if destructions[c] > 0 {
+			risk.MeanIteration = float64(destructionIterations[c]) / float64(destructions[c])
+		}
+
+		risks = append(risks, risk)
+	}
+	sortCityRisks(risks)
+
+	return Stats{
+		Outcomes:               outcomes,
+		CitiesDestroyed:        NewDistribution(citiesDestroyed),
+		AliensSurviving:        NewDistribution(aliensSurviving),
+		IterationsToExtinction: NewDistribution(iterationsToExtinction),
+		CityRisks:              risks,
+	}
+}
+
+// Distribution is a collection of integer samples that can be summarized.
+type Distribution struct {
+	sorted []int
+}
+
+// NewDistribution creates a new Distribution from the given samples.
+func NewDistribution(samples []int) Distribution {
+	sorted := make([]int, len(samples))
+	copy(sorted, samples)
+	sort.Ints(sorted)
+
+	return Distribution{sorted: sorted}
+}
+
+// Len returns the number of samples in the Distribution.
+func (d Distribution) Len() int {
+	return len(d.sorted)
+}
+
+// Min returns the smallest sample, or 0 if the Distribution is empty.
+func (d Distribution) Min() int {
+	if len(d.sorted) == 0 {
+		return 0
+	}
+
+	return d.sorted[0]
+}
+
+// Max returns the largest sample, or 0 if the Distribution is empty.
+func (d Distribution) Max() int {
+	if len(d.sorted) == 0 {
+		return 0
+	}
+
+	return d.sorted[len(d.sorted)-1]
+}
+
+// Mean returns the arithmetic mean of the samples, or 0 if the Distribution is empty.
+func (d Distribution) Mean() float64 {
+	if len(d.sorted) == 0 {
+		return 0
+	}
+
+	sum := 0
+	for _, s := range d.sorted {
+		sum += s
+	}
+
+	return float64(sum) / float64(len(d.sorted))
+}
+
+// Percentile returns the sample at percentile p (0 < p <= 100) using the nearest-rank method, or 0 if the
+// Distribution is empty.
+func (d Distribution) Percentile(p float64) int {
+	if len(d.sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(d.sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(d.sorted) {
+		rank = len(d.sorted)
+	}
+
+	return d.sorted[rank-1]
+}
+
+// WriteReport writes a human-readable summary of stats to out.
+func WriteReport(out io.Writer, stats Stats) error {
+	runs := len(stats.Outcomes)
+	extinctions := stats.IterationsToExtinction.Len()
+
+	fmt.Fprintf(out, "Runs: %d\n", runs)
+	fmt.Fprintf(out, "Runs where all aliens were destroyed: %d (%.1f%%)\n", extinctions, 100*float64(extinctions)/float64(runs))
+	fmt.Fprintln(out)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tmin\tmean\tp50\tp90\tp99\tmax\t")
+	writeDistribution(tw, "Cities destroyed", stats.CitiesDestroyed)
+	writeDistribution(tw, "Aliens surviving", stats.AliensSurviving)
+	writeDistribution(tw, "Iterations to extinction", stats.IterationsToExtinction)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Risk of destruction per city:")
+
+	return WriteCityRiskTable(out, stats.CityRisks)
+}
+
+// writeDistribution writes a row with the summary of d to tw.
+func writeDistribution(tw *tabwriter.Writer, name string, d Distribution) {
+	if d.Len() == 0 {
+		fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\t-\t\n", name)
+		return
+	}
+
+	fmt.Fprintf(
+		tw, "%s\t%d\t%.2f\t%d\t%d\t%d\t%d\t\n",
+		name, d.Min(), d.Mean(), d.Percentile(50), d.Percentile(90), d.Percentile(99), d.Max(),
+	)
+}
@@ -0,0 +1,113 @@
+package batch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_Distribution(t *testing.T) {
+	d := NewDistribution([]int{5, 1, 4, 2, 3, 10, 6, 8, 7, 9})
+
+	assert.Equal(t, 10, d.Len())
+	assert.Equal(t, 1, d.Min())
+	assert.Equal(t, 10, d.Max())
+	assert.Equal(t, 5.5, d.Mean())
+	assert.Equal(t, 5, d.Percentile(50))
+	assert.Equal(t, 9, d.Percentile(90))
+	assert.Equal(t, 10, d.Percentile(99))
+	assert.Equal(t, 1, d.Percentile(0))
+}
+
+func Test_Distribution_empty(t *testing.T) {
+	d := NewDistribution(nil)
+
+	assert.Equal(t, 0, d.Len())
+	assert.Equal(t, 0, d.Min())
+	assert.Equal(t, 0, d.Max())
+	assert.Equal(t, 0.0, d.Mean())
+	assert.Equal(t, 0, d.Percentile(50))
+}
+
+func Test_aggregate(t *testing.T) {
+	world := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_North: "Bar"},
+		"Bar": worldmap.Roads{worldmap.Direction_South: "Foo"},
+		"Baz": worldmap.Roads{},
+	}
+
+	outcomes := []Outcome{
+		{
+			Iterations:      3,
+			Reason:          simulation.TerminationReason_AllAliensDestroyed,
+			DestroyedCities: []simulation.DestroyedCity{{City: "Foo", Iteration: 3}},
+			AliensSurviving: 0,
+		},
+		{
+			Iterations:      10,
+			Reason:          simulation.TerminationReason_MaxIterationsReached,
+			AliensSurviving: 2,
+		},
+		{
+			Iterations:      5,
+			Reason:          simulation.TerminationReason_AllAliensDestroyed,
+			DestroyedCities: []simulation.DestroyedCity{{City: "Foo", Iteration: 2}, {City: "Bar", Iteration: 5}},
+			AliensSurviving: 0,
+		},
+		{
+			Iterations:      10,
+			Reason:          simulation.TerminationReason_MaxIterationsReached,
+			DestroyedCities: []simulation.DestroyedCity{{City: "Bar", Iteration: 7}},
+			AliensSurviving: 1,
+		},
+	}
+
+	stats := aggregate(world, outcomes)
+
+	assert.Equal(t, NewDistribution([]int{1, 0, 2, 1}), stats.CitiesDestroyed)
+	assert.Equal(t, NewDistribution([]int{0, 2, 0, 1}), stats.AliensSurviving)
+	assert.Equal(t, NewDistribution([]int{3, 5}), stats.IterationsToExtinction)
+	expectedRisks := []CityRisk{
+		{City: "Foo", Destructions: 2, Probability: 0.5, MeanIteration: 2.5},
+		{City: "Bar", Destructions: 2, Probability: 0.5, MeanIteration: 6},
+		{City: "Baz", Destructions: 0, Probability: 0, MeanIteration: 0},
+	}
+	assert.Equal(t, expectedRisks, stats.CityRisks)
+}
+
+func Test_WriteReport(t *testing.T) {
+	stats := Stats{
+		Outcomes:               make([]Outcome, 4),
+		CitiesDestroyed:        NewDistribution([]int{1, 0, 2, 1}),
+		AliensSurviving:        NewDistribution([]int{0, 2, 0, 1}),
+		IterationsToExtinction: NewDistribution([]int{3, 5}),
+		CityRisks: []CityRisk{
+			{City: "Bar", Destructions: 3, Probability: 0.75, MeanIteration: 4},
+			{City: "Foo", Destructions: 2, Probability: 0.5, MeanIteration: 2.5},
+			{City: "Baz", Destructions: 0, Probability: 0, MeanIteration: 0},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	err := WriteReport(out, stats)
+	assert.Nil(t, err)
+
+	expected := `Runs: 4
+Runs where all aliens were destroyed: 2 (50.0%)
+
+                            min  mean  p50  p90  p99  max
+          Cities destroyed    0  1.00    1    2    2    2
+          Aliens surviving    0  0.75    0    2    2    2
+  Iterations to extinction    3  4.00    3    5    5    5
+
+Risk of destruction per city:
+City  P(destroyed)  Mean iteration
+Bar   0.750         4.00
+Foo   0.500         2.50
+Baz   0.000         -
+`
+	assert.Equal(t, expected, out.String())
+}
@@ -0,0 +1,264 @@
+// Package generator creates procedurally generated worlds.
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// Kind is a kind of procedurally generated world.
+type Kind string
+
+const (
+	// Kind_Grid is a full rectangular grid, where every city has roads to all of its neighbours.
+	Kind_Grid Kind = "grid"
+	// Kind_Maze is a random spanning tree of a rectangular grid: there is exactly one path between any two cities.
+	Kind_Maze Kind = "maze"
+	// Kind_Sparse is a rectangular grid where roads are randomly removed. It may end up split into several islands.
+	Kind_Sparse Kind = "sparse"
+	// Kind_Islands is a row of full rectangular grids that are not connected to each other.
+	Kind_Islands Kind = "islands"
+	// Kind_Corridor is a single corridor winding through a rectangular grid from side to side, row after row.
+	Kind_Corridor Kind = "corridor"
+)
+
+// Kinds lists every kind of world that can be generated.
+var Kinds = []Kind{Kind_Grid, Kind_Maze, Kind_Sparse, Kind_Islands, Kind_Corridor}
+
+// Config holds the parameters of a generated world.
+type Config struct {
+	Kind Kind
+	// Width and Height are the size of the grid the world is generated on. For Kind_Islands, it is the size of every
+	// island.
+	Width  int
+	Height int
+	// Density is the probability of every road to be removed, between 0 and 1. Only used by Kind_Sparse.
+	Density float64
+	// Islands is the number of islands. Only used by Kind_Islands.
+	Islands int
+}
+
+// Generate generates a new world as described by cfg. Besides the world, the position of every city in the grid it
+// was generated on is returned. Cities get random names, and every random decision is taken using rng, so the same
+// config and seed always produce the same world.
+// Generated worlds are consistent by construction (see worldmap.CheckConsistency).
+func Generate(cfg Config, rng *rand.Rand) (worldmap.World, worldmap.Layout, error) {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, nil, errors.New("the width and height of the world must be greater than 0")
+	}
+
+	names := newNamer(rng)
+	switch cfg.Kind {
+	case Kind_Grid:
+		g := newGrid(cfg.Width, cfg.Height, names)
+		g.connectAll()
+
+		return g.world, g.layout, nil
+	case Kind_Maze:
+		g := newGrid(cfg.Width, cfg.Height, names)
+		g.carveMaze(rng)
+
+		return g.world, g.layout, nil
+	case Kind_Sparse:
+		if cfg.Density < 0 || cfg.Density > 1 {
+			return nil, nil, fmt.Errorf("the density of removed roads must be between 0 and 1, but it is %g", cfg.Density)
+		}
+
+		g := newGrid(cfg.Width, cfg.Height, names)
+		g.connectAll()
+		g.removeRoads(cfg.Density, rng)
+
+		return g.world, g.layout, nil
+	case Kind_Islands:
+		if cfg.Islands <= 0 {
+			return nil, nil, errors.New("the number of islands must be greater than 0")
+		}
+
+		world, layout := worldmap.World{}, worldmap.Layout{}
+		for i := 0; i < cfg.Islands; i++ {
+			g := newGrid(cfg.Width, cfg.Height, names)
+			g.connectAll()
+
+			// leave an empty column between islands
+			offsetX := i * (cfg.Width + 1)
+			for c, pos := range g.layout {
+				world[c] = g.world[c]
+				layout[c] = worldmap.Coords{X: pos.X + offsetX, Y: pos.Y}
+			}
+		}
+
+		return world, layout, nil
+	case Kind_Corridor:
+		g := newGrid(cfg.Width, cfg.Height, names)
+		g.carveCorridor()
+
+		return g.world, g.layout, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown kind of world %q", cfg.Kind)
+	}
+}
+
+// grid is a rectangular grid of cities, with no roads between them at first. Cells are numbered from the bottom-left
+// corner, row after row, so the cell north of cell i is i + width.
+type grid struct {
+	width  int
+	height int
+	cities []string
+	world  worldmap.World
+	layout worldmap.Layout
+}
+
+// newGrid creates a new grid of the given size, naming its cities with names.
+func newGrid(width, height int, names *namer) *grid {
+	g := &grid{
+		width:  width,
+		height: height,
+		cities: make([]string, width*height),
+		world:  make(worldmap.World, width*height),
+		layout: make(worldmap.Layout, width*height),
+	}
+
+	for i := range g.cities {
+		name := names.next()
+		g.cities[i] = name
+		g.world[name] = worldmap.Roads{}
+		g.layout[name] = worldmap.Coords{X: i % width, Y: i / width}
+	}
+
+	return g
+}
+
+// neighbours returns the cells next to cell, in the order given by worldmap.Directions.
+func (g *grid) neighbours(cell int) []int {
+	x, y := cell%g.width, cell/g.width
+	neighbours := make([]int, 0, 4)
+	if y+1 < g.height {
+		neighbours = append(neighbours, cell+g.width)
+	}
+	if x+1 < g.width {
+		neighbours = append(neighbours, cell+1)
+	}
+	if y > 0 {
+		neighbours = append(neighbours, cell-g.width)
+	}
+	if x > 0 {
+		neighbours = append(neighbours, cell-1)
+	}
+
+	return neighbours
+}
+
+// edges returns every pair of neighbouring cells once, with the western or southern cell first.
+func (g *grid) edges() [][2]int {
+	edges := [][2]int{}
+	for cell := range g.cities {
+		x, y := cell%g.width, cell/g.width
+		if x+1 < g.width {
+			edges = append(edges, [2]int{cell, cell + 1})
+		}
+		if y+1 < g.height {
+			edges = append(edges, [2]int{cell, cell + g.width})
+		}
+	}
+
+	return edges
+}
+
+// link adds a road between the cities in cells a and b, which must be neighbours, and the road back.
+func (g *grid) link(a, b int) {
+	if a > b {
+		a, b = b, a
+	}
+
+	west, east := g.cities[a], g.cities[b]
+	if b == a+1 {
+		g.world[west][worldmap.Direction_East] = east
+		g.world[east][worldmap.Direction_West] = west
+	} else {
+		south, north := west, east
+		g.world[south][worldmap.Direction_North] = north
+		g.world[north][worldmap.Direction_South] = south
+	}
+}
+
+// unlink removes the roads between the cities in cells a and b, which must be neighbours.
+func (g *grid) unlink(a, b int) {
+	if a > b {
+		a, b = b, a
+	}
+
+	if b == a+1 {
+		delete(g.world[g.cities[a]], worldmap.Direction_East)
+		delete(g.world[g.cities[b]], worldmap.Direction_West)
+	} else {
+		delete(g.world[g.cities[a]], worldmap.Direction_North)
+		delete(g.world[g.cities[b]], worldmap.Direction_South)
+	}
+}
+
+// connectAll adds roads between every pair of neighbouring cities.
+func (g *grid) connectAll() {
+	for _, e := range g.edges() {
+		g.link(e[0], e[1])
+	}
+}
+
+// removeRoads removes every road with the given probability.
+func (g *grid) removeRoads(density float64, rng *rand.Rand) {
+	for _, e := range g.edges() {
+		if rng.Float64() < density {
+			g.unlink(e[0], e[1])
+		}
+	}
+}
+
+// carveMaze adds the roads of a random spanning tree of the grid, using a randomized depth-first search that starts
+// at a random cell.
+func (g *grid) carveMaze(rng *rand.Rand) {
+	visited := make([]bool, len(g.cities))
+	start := rng.Intn(len(g.cities))
+	visited[start] = true
+	stack := []int{start}
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+
+		candidates := []int{}
+		for _, n := range g.neighbours(cell) {
+			if !visited[n] {
+				candidates = append(candidates, n)
+			}
+		}
+
+		if len(candidates) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		next := candidates[rng.Intn(len(candidates))]
+		g.link(cell, next)
+		visited[next] = true
+		stack = append(stack, next)
+	}
+}
+
+// carveCorridor adds the roads of a corridor that runs through every row of the grid, turning north at the eastern
+// end of even rows and at the western end of odd ones.
+func (g *grid) carveCorridor() {
+	for y := 0; y < g.height; y++ {
+		row := y * g.width
+		for x := 0; x+1 < g.width; x++ {
+			g.link(row+x, row+x+1)
+		}
+
+		if y+1 < g.height {
+			turn := row
+			if y%2 == 0 {
+				turn = row + g.width - 1
+			}
+			g.link(turn, turn+g.width)
+		}
+	}
+}
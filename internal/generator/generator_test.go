@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"bytes"
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// countRoads returns the number of two-way roads in world.
+func countRoads(world worldmap.World) int {
+	roads := 0
+	for _, r := range world {
+		roads += len(r)
+	}
+
+	return roads / 2
+}
+
+// countIslands returns the number of groups of cities in world that are connected to each other.
+func countIslands(world worldmap.World) int {
+	visited := map[string]bool{}
+	islands := 0
+	for _, origin := range world.Cities() {
+		if visited[origin] {
+			continue
+		}
+
+		islands++
+		visited[origin] = true
+		queue := []string{origin}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, dest := range world[current] {
+				if !visited[dest] {
+					visited[dest] = true
+					queue = append(queue, dest)
+				}
+			}
+		}
+	}
+
+	return islands
+}
+
+func Test_Generate(t *testing.T) {
+	testCases := map[string]struct {
+		cfg             Config
+		expectedCities  int
+		expectedRoads   int
+		expectedIslands int
+	}{
+		"grid": {
+			cfg:             Config{Kind: Kind_Grid, Width: 4, Height: 3},
+			expectedCities:  12,
+			expectedRoads:   17,
+			expectedIslands: 1,
+		},
+		"maze": {
+			cfg:             Config{Kind: Kind_Maze, Width: 5, Height: 4},
+			expectedCities:  20,
+			expectedRoads:   19,
+			expectedIslands: 1,
+		},
+		"sparse without removed roads": {
+			cfg:             Config{Kind: Kind_Sparse, Width: 3, Height: 3, Density: 0},
+			expectedCities:  9,
+			expectedRoads:   12,
+			expectedIslands: 1,
+		},
+		"sparse with every road removed": {
+			cfg:             Config{Kind: Kind_Sparse, Width: 3, Height: 3, Density: 1},
+			expectedCities:  9,
+			expectedRoads:   0,
+			expectedIslands: 9,
+		},
+		"islands": {
+			cfg:             Config{Kind: Kind_Islands, Width: 2, Height: 2, Islands: 3},
+			expectedCities:  12,
+			expectedRoads:   12,
+			expectedIslands: 3,
+		},
+		"corridor": {
+			cfg:             Config{Kind: Kind_Corridor, Width: 4, Height: 3},
+			expectedCities:  12,
+			expectedRoads:   11,
+			expectedIslands: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			world, layout, err := Generate(tc.cfg, rand.New(rand.NewSource(1)))
+			assert.Nil(t, err)
+
+			assert.Len(t, world, tc.expectedCities)
+			assert.Equal(t, tc.expectedRoads, countRoads(world))
+			assert.Equal(t, tc.expectedIslands, countIslands(world))
+			assert.Nil(t, worldmap.CheckConsistency(world))
+
+			// every city has its own position, and roads agree with them
+			assert.Len(t, layout, len(world))
+			assert.Len(t, layout.Grid(), len(world))
+			buf := &bytes.Buffer{}
+			err = worldmap.FormatJSON(buf, world, worldmap.FormatOptions{Layout: layout})
+			assert.Nil(t, err)
+			_, err = worldmap.ParseJSON(buf)
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func Test_Generate_sameSeed(t *testing.T) {
+	cfg := Config{Kind: Kind_Sparse, Width: 6, Height: 6, Density: 0.4}
+
+	world1, layout1, err := Generate(cfg, rand.New(rand.NewSource(42)))
+	assert.Nil(t, err)
+	world2, layout2, err := Generate(cfg, rand.New(rand.NewSource(42)))
+	assert.Nil(t, err)
+
+	assert.Equal(t, world1, world2)
+	assert.Equal(t, layout1, layout2)
+}
+
+func Test_Generate_writesValidMapFiles(t *testing.T) {
+	world, _, err := Generate(Config{Kind: Kind_Maze, Width: 8, Height: 8}, rand.New(rand.NewSource(7)))
+	assert.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, worldmap.Format(buf, world, worldmap.FormatOptions{}))
+
+	mapFile, err := worldmap.Parse(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, world, mapFile.World)
+}
+
+func Test_Generate_errors(t *testing.T) {
+	testCases := map[string]Config{
+		"unknown kind":     {Kind: "spiral", Width: 2, Height: 2},
+		"zero width":       {Kind: Kind_Grid, Width: 0, Height: 2},
+		"negative height":  {Kind: Kind_Grid, Width: 2, Height: -1},
+		"density too high": {Kind: Kind_Sparse, Width: 2, Height: 2, Density: 1.5},
+		"negative density": {Kind: Kind_Sparse, Width: 2, Height: 2, Density: -0.1},
+		"no islands":       {Kind: Kind_Islands, Width: 2, Height: 2, Islands: 0},
+	}
+
+	for name, cfg := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Generate(cfg, rand.New(rand.NewSource(1)))
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func Test_namer(t *testing.T) {
+	names := newNamer(rand.New(rand.NewSource(1)))
+	valid := regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+	seen := map[string]bool{}
+	for i := 0; i < 5000; i++ {
+		name := names.next()
+		assert.Regexp(t, valid, name)
+		assert.False(t, seen[name], "name %q repeated", name)
+		seen[name] = true
+	}
+}
@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+
+	"github.com/volmedo/invasim/internal/splitmix"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// InfiniteConfig holds the parameters of an InfiniteGrid.
+type InfiniteConfig struct {
+	// Seed decides which roads exist. Grids with the same seed and density have the same roads.
+	Seed int64
+	// Density is the probability of every road to be removed, between 0 and 1.
+	Density float64
+	// SpawnRadius is the maximum distance, along each axis, from the origin of the cities where aliens can be placed.
+	SpawnRadius int
+}
+
+// InfiniteGrid is a worldmap.Terrain made of an unbounded rectangular grid of cities, where some roads are randomly
+// removed like in Kind_Sparse worlds. Nothing is stored about cities until they are destroyed: whether a road exists
+// is decided on demand from the coordinates of its ends and the seed, so the grid takes as much memory as the number
+// of destroyed cities and roads.
+//
+// Cities are named after their coordinates, like "E3N12" for the city 3 cells east and 12 cells north of the origin,
+// or "W1N0" for the city right west of it.
+type InfiniteGrid struct {
+	cfg       InfiniteConfig
+	destroyed map[worldmap.Coords]bool
+	// removed holds the destroyed roads, identified by their ends like in hasRoad
+	removed map[[2]worldmap.Coords]bool
+}
+
+// NewInfiniteGrid creates a new InfiniteGrid as described by cfg.
+func NewInfiniteGrid(cfg InfiniteConfig) (*InfiniteGrid, error) {
+	if cfg.Density < 0 || cfg.Density > 1 {
+		return nil, fmt.Errorf("the density of removed roads must be between 0 and 1, but it is %g", cfg.Density)
+	}
+
+	if cfg.SpawnRadius < 0 {
+		return nil, errors.New("the spawn radius must not be negative")
+	}
+
+	return &InfiniteGrid{
+		cfg:       cfg,
+		destroyed: map[worldmap.Coords]bool{},
+		removed:   map[[2]worldmap.Coords]bool{},
+	}, nil
+}
+
+// Roads implements the worldmap.Terrain interface.
+func (g *InfiniteGrid) Roads(city string) worldmap.Roads {
+	pos, ok := ParseCityName(city)
+	if !ok || g.destroyed[pos] {
+		return worldmap.Roads{}
+	}
+
+	roads := worldmap.Roads{}
+	for _, dir := range worldmap.Directions {
+		dest := neighbour(pos, dir)
+		if !g.destroyed[dest] && g.hasRoad(pos, dest) {
+			roads[dir] = CityName(dest)
+		}
+	}
+
+	return roads
+}
+
+// DestroyCity implements the worldmap.Terrain interface.
+func (g *InfiniteGrid) DestroyCity(city string) {
+	if pos, ok := ParseCityName(city); ok {
+		g.destroyed[pos] = true
+	}
+}
+
+// DestroyRoad implements the worldmap.Terrain interface.
+func (g *InfiniteGrid) DestroyRoad(city string, dir worldmap.Direction) {
+	if pos, ok := ParseCityName(city); ok {
+		g.removed[roadEnds(pos, neighbour(pos, dir))] = true
+	}
+}
+
+// RandomCities implements the worldmap.Terrain interface. Cities are chosen among the ones that have not been
+// destroyed within SpawnRadius of the origin.
+func (g *InfiniteGrid) RandomCities(n int, rng *rand.Rand) ([]string, error) {
+	side := 2*g.cfg.SpawnRadius + 1
+	available := side * side
+	for pos := range g.destroyed {
+		if abs(pos.X) <= g.cfg.SpawnRadius && abs(pos.Y) <= g.cfg.SpawnRadius {
+			available--
+		}
+	}
+
+	if n > available {
+		return nil, fmt.Errorf("not enough cities (%d) to place %d aliens", available, n)
+	}
+
+	cities := make([]string, 0, n)
+	taken := make(map[worldmap.Coords]bool, n)
+	for len(cities) < n {
+		pos := worldmap.Coords{X: rng.Intn(side) - g.cfg.SpawnRadius, Y: rng.Intn(side) - g.cfg.SpawnRadius}
+		if taken[pos] || g.destroyed[pos] {
+			continue
+		}
+
+		taken[pos] = true
+		cities = append(cities, CityName(pos))
+	}
+
+	return cities, nil
+}
+
+// hasRoad reports whether the road between the neighbouring cities at a and b exists, ignoring destroyed cities.
+func (g *InfiniteGrid) hasRoad(a, b worldmap.Coords) bool {
+	ends := roadEnds(a, b)
+	if g.removed[ends] {
+		return false
+	}
+
+	// every road is identified by its western or southern end and whether it runs east or north from it
+	a, b = ends[0], ends[1]
+
+	axis := uint64(0)
+	if b.Y > a.Y {
+		axis = 1
+	}
+
+	h := splitmix.Mix(uint64(g.cfg.Seed))
+	h = splitmix.Mix(h ^ uint64(int64(a.X)))
+	h = splitmix.Mix(h ^ uint64(int64(a.Y)))
+	h = splitmix.Mix(h ^ axis)
+
+	// use the 53 most significant bits to get a float64 in [0, 1)
+	return float64(h>>11)/(1<<53) >= g.cfg.Density
+}
+
+// roadEnds returns the ends of the road between the neighbouring cities at a and b, western or southern end first.
+func roadEnds(a, b worldmap.Coords) [2]worldmap.Coords {
+	if b.X < a.X || b.Y < a.Y {
+		a, b = b, a
+	}
+
+	return [2]worldmap.Coords{a, b}
+}
+
+// neighbour returns the coordinates of the cell next to pos in the given direction.
+func neighbour(pos worldmap.Coords, dir worldmap.Direction) worldmap.Coords {
+	switch dir {
+	case worldmap.Direction_North:
+		pos.Y++
+	case worldmap.Direction_East:
+		pos.X++
+	case worldmap.Direction_South:
+		pos.Y--
+	case worldmap.Direction_West:
+		pos.X--
+	}
+
+	return pos
+}
+
+var cityNameRegexp = regexp.MustCompile(`^([EW])(\d+)([NS])(\d+)$`)
+
+// CityName returns the name of the city of an InfiniteGrid at pos.
+func CityName(pos worldmap.Coords) string {
+	ew, ns := "E", "N"
+	if pos.X < 0 {
+		ew = "W"
+	}
+	if pos.Y < 0 {
+		ns = "S"
+	}
+
+	return ew + strconv.Itoa(abs(pos.X)) + ns + strconv.Itoa(abs(pos.Y))
+}
+
+// ParseCityName returns the coordinates of the city of an InfiniteGrid with the given name. It returns false if name is
+// not the name of any city, which includes non-canonical names like "W0N0".
+func ParseCityName(name string) (worldmap.Coords, bool) {
+	match := cityNameRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return worldmap.Coords{}, false
+	}
+
+	x, errX := strconv.Atoi(match[2])
+	y, errY := strconv.Atoi(match[4])
+	if errX != nil || errY != nil {
+		return worldmap.Coords{}, false
+	}
+
+	if match[1] == "W" {
+		x = -x
+	}
+	if match[3] == "S" {
+		y = -y
+	}
+
+	pos := worldmap.Coords{X: x, Y: y}
+	if CityName(pos) != name {
+		return worldmap.Coords{}, false
+	}
+
+	return pos, true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
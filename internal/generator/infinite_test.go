@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_CityName(t *testing.T) {
+	testCases := map[string]worldmap.Coords{
+		"E0N0":  {X: 0, Y: 0},
+		"E3N12": {X: 3, Y: 12},
+		"W1N0":  {X: -1, Y: 0},
+		"W7S42": {X: -7, Y: -42},
+	}
+
+	for name, pos := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, name, CityName(pos))
+
+			parsed, ok := ParseCityName(name)
+			assert.True(t, ok)
+			assert.Equal(t, pos, parsed)
+		})
+	}
+
+	for _, name := range []string{"", "Foo", "W0N0", "E0S0", "E01N1", "N1E1", "E1N"} {
+		_, ok := ParseCityName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func Test_InfiniteGrid_Roads(t *testing.T) {
+	grid, err := NewInfiniteGrid(InfiniteConfig{Seed: 1, Density: 0.4, SpawnRadius: 5})
+	assert.Nil(t, err)
+
+	// roads always have a road back, and they are the same every time they are requested
+	for x := -20; x <= 20; x++ {
+		for y := -20; y <= 20; y++ {
+			city := CityName(worldmap.Coords{X: x, Y: y})
+			roads := grid.Roads(city)
+			assert.Equal(t, roads, grid.Roads(city))
+
+			for dir, dest := range roads {
+				assert.Equal(t, neighbour(worldmap.Coords{X: x, Y: y}, dir), mustParse(t, dest))
+				assert.Contains(t, grid.Roads(dest), opposite(dir))
+				assert.Equal(t, city, grid.Roads(dest)[opposite(dir)])
+			}
+		}
+	}
+
+	full, err := NewInfiniteGrid(InfiniteConfig{Seed: 1, Density: 0})
+	assert.Nil(t, err)
+	assert.Len(t, full.Roads("W1000S1000"), 4)
+
+	empty, err := NewInfiniteGrid(InfiniteConfig{Seed: 1, Density: 1})
+	assert.Nil(t, err)
+	assert.Empty(t, empty.Roads("E0N0"))
+
+	assert.Empty(t, full.Roads("Foo"))
+}
+
+func Test_InfiniteGrid_DestroyCity(t *testing.T) {
+	grid, err := NewInfiniteGrid(InfiniteConfig{Seed: 1, Density: 0})
+	assert.Nil(t, err)
+
+	grid.DestroyCity("E0N0")
+
+	assert.Empty(t, grid.Roads("E0N0"))
+	assert.NotContains(t, grid.Roads("E0N1"), worldmap.Direction_South)
+	assert.NotContains(t, grid.Roads("E1N0"), worldmap.Direction_West)
+	assert.NotContains(t, grid.Roads("E0S1"), worldmap.Direction_North)
+	assert.NotContains(t, grid.Roads("W1N0"), worldmap.Direction_East)
+	assert.Len(t, grid.Roads("E1N1"), 4)
+}
+
+func Test_InfiniteGrid_DestroyRoad(t *testing.T) {
+	grid, err := NewInfiniteGrid(InfiniteConfig{Seed: 1, Density: 0})
+	assert.Nil(t, err)
+
+	grid.DestroyRoad("E0N0", worldmap.Direction_East)
+
+	assert.Len(t, grid.Roads("E0N0"), 3)
+	assert.NotContains(t, grid.Roads("E0N0"), worldmap.Direction_East)
+	assert.NotContains(t, grid.Roads("E1N0"), worldmap.Direction_West)
+	assert.Len(t, grid.Roads("E1N0"), 3)
+}
+
+func Test_InfiniteGrid_RandomCities(t *testing.T) {
+	grid, err := NewInfiniteGrid(InfiniteConfig{Seed: 1, Density: 0, SpawnRadius: 1})
+	assert.Nil(t, err)
+	grid.DestroyCity("E0N0")
+
+	cities, err := grid.RandomCities(8, rand.New(rand.NewSource(1)))
+	assert.Nil(t, err)
+	assert.Len(t, cities, 8)
+	assert.NotContains(t, cities, "E0N0")
+
+	seen := map[string]bool{}
+	for _, c := range cities {
+		pos := mustParse(t, c)
+		assert.True(t, abs(pos.X) <= 1 && abs(pos.Y) <= 1)
+		assert.False(t, seen[c])
+		seen[c] = true
+	}
+
+	_, err = grid.RandomCities(9, rand.New(rand.NewSource(1)))
+	assert.NotNil(t, err)
+}
+
+func Test_InfiniteGrid_invasion(t *testing.T) {
+	run := func() simulation.Result {
+		grid, err := NewInfiniteGrid(InfiniteConfig{Seed: 3, Density: 0.2, SpawnRadius: 3})
+		assert.Nil(t, err)
+
+		rng := rand.New(rand.NewSource(3))
+		tracker, err := aliens.NewTracker(20, grid, nil, rng)
+		assert.Nil(t, err)
+
+		return simulation.Run(grid, tracker, 200, rng, nil, simulation.Options{})
+	}
+
+	result := run()
+	assert.NotEmpty(t, result.DestroyedCities)
+	assert.Nil(t, result.World)
+	assert.Equal(t, result, run())
+}
+
+func Test_NewInfiniteGrid_errors(t *testing.T) {
+	testCases := map[string]InfiniteConfig{
+		"density too high":      {Density: 1.1},
+		"negative density":      {Density: -0.5},
+		"negative spawn radius": {SpawnRadius: -1},
+	}
+
+	for name, cfg := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewInfiniteGrid(cfg)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func mustParse(t *testing.T, city string) worldmap.Coords {
+	pos, ok := ParseCityName(city)
+	assert.True(t, ok, city)
+
+	return pos
+}
+
+func opposite(dir worldmap.Direction) worldmap.Direction {
+	switch dir {
+	case worldmap.Direction_North:
+		return worldmap.Direction_South
+	case worldmap.Direction_East:
+		return worldmap.Direction_West
+	case worldmap.Direction_South:
+		return worldmap.Direction_North
+	default:
+		return worldmap.Direction_East
+	}
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+var syllableConsonants = []string{"b", "d", "f", "g", "k", "l", "m", "n", "p", "r", "s", "t", "v", "z"}
+var syllableVowels = []string{"a", "e", "i", "o", "u"}
+
+// maxNameAttempts is the number of random names tried before falling back to adding a number to make a name unique.
+const maxNameAttempts = 10
+
+// namer creates unique city names that follow the grammar of map files, i.e. made only of letters and digits.
+type namer struct {
+	rng   *rand.Rand
+	taken map[string]bool
+}
+
+// newNamer creates a new namer that takes every random decision using rng.
+func newNamer(rng *rand.Rand) *namer {
+	return &namer{rng: rng, taken: map[string]bool{}}
+}
+
+// next returns a new name made of 2 to 4 random syllables, like "Belora". Names are never repeated: if no unique name
+// is found after a few attempts, a number is appended to the last one tried.
+func (n *namer) next() string {
+	name := ""
+	for i := 0; i < maxNameAttempts; i++ {
+		name = n.randomName()
+		if !n.taken[name] {
+			n.taken[name] = true
+			return name
+		}
+	}
+
+	for suffix := 2; ; suffix++ {
+		candidate := name + strconv.Itoa(suffix)
+		if !n.taken[candidate] {
+			n.taken[candidate] = true
+			return candidate
+		}
+	}
+}
+
+// randomName creates a random name, which may have been created before.
+func (n *namer) randomName() string {
+	syllables := n.rng.Intn(3) + 2
+	name := strings.Builder{}
+	for i := 0; i < syllables; i++ {
+		name.WriteString(syllableConsonants[n.rng.Intn(len(syllableConsonants))])
+		name.WriteString(syllableVowels[n.rng.Intn(len(syllableVowels))])
+	}
+
+	return strings.ToUpper(name.String()[:1]) + name.String()[1:]
+}
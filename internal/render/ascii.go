@@ -0,0 +1,88 @@
+package render
+
+import (
+	"io"
+	"strings"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// markers used to draw the different elements of a world in ASCII
+const (
+	emptyMarker          = "."
+	craterMarker         = "*"
+	eastWestRoadMarker   = "-"
+	northSouthRoadMarker = "|"
+)
+
+// ASCII draws world as a text grid, placing its cities according to layout. Cities are drawn as their names, padded
+// so that every cell in the grid has the same width, and empty cells are drawn as dots. Roads between cities are drawn
+// as "-" and "|" characters between cells.
+//
+// layout can hold cities that are no longer part of world, like when it was computed before an invasion. Those cities
+// are drawn as craters, filling their cell with asterisks.
+func ASCII(out io.Writer, world worldmap.World, layout worldmap.Layout) error {
+	if len(layout) == 0 {
+		return nil
+	}
+
+	width := 1
+	for c := range layout {
+		if len(c) > width {
+			width = len(c)
+		}
+	}
+
+	grid := layout.Grid()
+	min, max := layout.Bounds()
+	lines := []string{}
+	for y := max.Y; y >= min.Y; y-- {
+		cityLine := strings.Builder{}
+		roadLine := strings.Builder{}
+		for x := min.X; x <= max.X; x++ {
+			city, occupied := grid[worldmap.Coords{X: x, Y: y}]
+			roads, exists := world[city]
+
+			switch {
+			case !occupied:
+				cityLine.WriteString(pad(emptyMarker, width))
+			case !exists:
+				cityLine.WriteString(strings.Repeat(craterMarker, width))
+			default:
+				cityLine.WriteString(pad(city, width))
+			}
+
+			if x < max.X {
+				if exists && roads[worldmap.Direction_East] != "" {
+					cityLine.WriteString(" " + eastWestRoadMarker + " ")
+				} else {
+					cityLine.WriteString("   ")
+				}
+			}
+
+			if exists && roads[worldmap.Direction_South] != "" {
+				roadLine.WriteString(pad(northSouthRoadMarker, width+3))
+			} else {
+				roadLine.WriteString(pad("", width+3))
+			}
+		}
+
+		lines = append(lines, strings.TrimRight(cityLine.String(), " "))
+		if y > min.Y {
+			lines = append(lines, strings.TrimRight(roadLine.String(), " "))
+		}
+	}
+
+	_, err := io.WriteString(out, strings.Join(lines, "\n")+"\n")
+
+	return err
+}
+
+// pad pads s with spaces to the right until it is width characters long.
+func pad(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_ASCII(t *testing.T) {
+	// Bee --- Bar
+	//          |
+	// Baz --- Foo
+	//          |
+	//         Qu-ux
+	world := worldmap.World{
+		"Foo": worldmap.Roads{
+			worldmap.Direction_North: "Bar",
+			worldmap.Direction_West:  "Baz",
+			worldmap.Direction_South: "Qu-ux",
+		},
+		"Bar": worldmap.Roads{
+			worldmap.Direction_South: "Foo",
+			worldmap.Direction_West:  "Bee",
+		},
+		"Baz": worldmap.Roads{
+			worldmap.Direction_East: "Foo",
+		},
+		"Qu-ux": worldmap.Roads{
+			worldmap.Direction_North: "Foo",
+		},
+		"Bee": worldmap.Roads{
+			worldmap.Direction_East: "Bar",
+		},
+	}
+
+	layout, err := worldmap.NewLayout(world)
+	assert.Nil(t, err)
+
+	testCases := map[string]struct {
+		cityToDestroy string
+		expected      string
+	}{
+		"intact world": {
+			expected: "" +
+				"Bee   - Bar\n" +
+				"        |\n" +
+				"Baz   - Foo\n" +
+				"        |\n" +
+				".       Qu-ux\n",
+		},
+		"destroyed city": {
+			cityToDestroy: "Foo",
+			expected: "" +
+				"Bee   - Bar\n" +
+				"\n" +
+				"Baz     *****\n" +
+				"\n" +
+				".       Qu-ux\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := world.Copy()
+			if tc.cityToDestroy != "" {
+				w.DestroyCity(tc.cityToDestroy)
+			}
+
+			out := &bytes.Buffer{}
+			err := ASCII(out, w, layout)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func Test_ASCII_readGrid(t *testing.T) {
+	world := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_North: "Bar", worldmap.Direction_East: "Kaa"},
+		"Bar": worldmap.Roads{worldmap.Direction_South: "Foo"},
+		"Kaa": worldmap.Roads{worldmap.Direction_West: "Foo"},
+		"Xen": worldmap.Roads{},
+	}
+
+	layout, err := worldmap.NewLayout(world)
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	err = ASCII(out, world, layout)
+	assert.Nil(t, err)
+
+	// drawings can be read back as grid map files
+	mapFile, err := worldmap.ReadGrid(out)
+	assert.Nil(t, err)
+	assert.Equal(t, world, mapFile.World)
+	assert.Equal(t, layout, mapFile.Layout)
+}
@@ -0,0 +1,198 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"sort"
+
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+const (
+	// gifCellSize is the size, in pixels, of the square cell every city is drawn in.
+	gifCellSize = 24
+	// gifCityInset is the gap, in pixels, between the border of a cell and the city drawn inside.
+	gifCityInset = 5
+	// gifRoadWidth is the width, in pixels, of roads.
+	gifRoadWidth = 2
+	// gifAlienRadius is the radius, in pixels, of the dots aliens are drawn as.
+	gifAlienRadius = 5
+	// gifFrameDelay is the time each frame is shown, in 100ths of a second.
+	gifFrameDelay = 20
+)
+
+// gifPalette holds the colours used to draw frames. The first four are used for the background, cities, roads and
+// craters, in that order, and the rest are used to tell aliens apart.
+var gifPalette = color.Palette{
+	color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	color.RGBA{R: 0xbb, G: 0xbb, B: 0xbb, A: 0xff},
+	color.RGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff},
+	color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
+	color.RGBA{R: 0xe6, G: 0x19, B: 0x4b, A: 0xff},
+	color.RGBA{R: 0x3c, G: 0xb4, B: 0x4b, A: 0xff},
+	color.RGBA{R: 0x43, G: 0x63, B: 0xd8, A: 0xff},
+	color.RGBA{R: 0xf5, G: 0x82, B: 0x31, A: 0xff},
+	color.RGBA{R: 0x91, G: 0x1e, B: 0xb4, A: 0xff},
+	color.RGBA{R: 0x42, G: 0xd4, B: 0xf4, A: 0xff},
+	color.RGBA{R: 0xf0, G: 0x32, B: 0xe6, A: 0xff},
+	color.RGBA{R: 0xbf, G: 0xef, B: 0x45, A: 0xff},
+}
+
+const (
+	backgroundColorIndex = iota
+	cityColorIndex
+	roadColorIndex
+	craterColorIndex
+	firstAlienColorIndex
+)
+
+// GIFRecorder is a simulation.Observer that draws a frame of an animated GIF for every iteration of a simulation, plus
+// one for the initial placement of aliens. Iterations in which nothing happens don't produce new frames. Cities are
+// drawn as grey cells placed according to a Layout, roads as lines between them, aliens as coloured dots
+// and destroyed cities as black cells.
+//
+// The recorder keeps its own copy of the world, which it updates as events are received, so the simulation can
+// mutate the original freely.
+type GIFRecorder struct {
+	world     worldmap.World
+	layout    worldmap.Layout
+	positions map[string]string
+	colors    map[string]uint8
+	iteration int
+	anim      gif.GIF
+}
+
+// NewGIFRecorder creates a new GIFRecorder that draws world, as it is before the simulation starts, using layout.
+func NewGIFRecorder(world worldmap.World, layout worldmap.Layout) *GIFRecorder {
+	return &GIFRecorder{
+		world:     world.Copy(),
+		layout:    layout,
+		positions: map[string]string{},
+		colors:    map[string]uint8{},
+	}
+}
+
+// Notify implements the simulation.Observer interface.
+func (r *GIFRecorder) Notify(event simulation.Event) {
+	if event.Iteration > r.iteration {
+		r.drawFrame()
+		r.iteration = event.Iteration
+	}
+
+	switch event.Type {
+	case simulation.EventType_AlienPlaced:
+		r.positions[event.Alien] = event.City
+		r.colors[event.Alien] = uint8(firstAlienColorIndex + len(r.colors)%(len(gifPalette)-firstAlienColorIndex))
+
+	case simulation.EventType_AlienMoved, simulation.EventType_AlienDugOut:
+		r.positions[event.Alien] = event.To
+
+	case simulation.EventType_AlienStranded:
+		delete(r.positions, event.Alien)
+
+	case simulation.EventType_CityDestroyed:
+		r.world.DestroyCity(event.City)
+		for _, a := range event.Aliens {
+			delete(r.positions, a)
+		}
+
+	case simulation.EventType_CityDefended:
+		for _, a := range event.Aliens {
+			delete(r.positions, a)
+		}
+
+	// survivors are reported after the city they fought in is destroyed or defended
+	case simulation.EventType_AlienSurvived:
+		r.positions[event.Alien] = event.City
+
+	case simulation.EventType_RoadDestroyed:
+		r.world.DestroyRoad(event.From, event.Direction)
+
+	case simulation.EventType_AliensDestroyed:
+		for _, a := range event.Aliens {
+			delete(r.positions, a)
+		}
+
+	case simulation.EventType_SimulationEnded:
+		r.drawFrame()
+	}
+}
+
+// Encode writes the recorded animation to out in GIF format.
+func (r *GIFRecorder) Encode(out io.Writer) error {
+	return gif.EncodeAll(out, &r.anim)
+}
+
+// drawFrame draws the current state of the world and appends it to the animation.
+func (r *GIFRecorder) drawFrame() {
+	min, max := r.layout.Bounds()
+	bounds := image.Rect(0, 0, (max.X-min.X+1)*gifCellSize, (max.Y-min.Y+1)*gifCellSize)
+	frame := image.NewPaletted(bounds, gifPalette)
+
+	// cellOrigin returns the top-left corner of the cell a city at pos is drawn in
+	cellOrigin := func(pos worldmap.Coords) image.Point {
+		return image.Pt((pos.X-min.X)*gifCellSize, (max.Y-pos.Y)*gifCellSize)
+	}
+
+	fill(frame, bounds, backgroundColorIndex)
+
+	// roads go first so that cities are drawn on top of them
+	half := gifCellSize / 2
+	for _, c := range r.layout.Cities() {
+		roads := r.world[c]
+		origin := cellOrigin(r.layout[c])
+		if _, ok := roads[worldmap.Direction_East]; ok {
+			road := image.Rect(half, half-gifRoadWidth/2, gifCellSize+half, half+gifRoadWidth/2).Add(origin)
+			fill(frame, road, roadColorIndex)
+		}
+		if _, ok := roads[worldmap.Direction_South]; ok {
+			road := image.Rect(half-gifRoadWidth/2, half, half+gifRoadWidth/2, gifCellSize+half).Add(origin)
+			fill(frame, road, roadColorIndex)
+		}
+	}
+
+	for _, c := range r.layout.Cities() {
+		cell := image.Rect(gifCityInset, gifCityInset, gifCellSize-gifCityInset, gifCellSize-gifCityInset)
+		cell = cell.Add(cellOrigin(r.layout[c]))
+		if _, exists := r.world[c]; exists {
+			fill(frame, cell, cityColorIndex)
+		} else {
+			fill(frame, cell, craterColorIndex)
+		}
+	}
+
+	aliens := make([]string, 0, len(r.positions))
+	for a := range r.positions {
+		aliens = append(aliens, a)
+	}
+	sort.Strings(aliens)
+
+	for _, a := range aliens {
+		center := cellOrigin(r.layout[r.positions[a]]).Add(image.Pt(half, half))
+		fillCircle(frame, center, gifAlienRadius, r.colors[a])
+	}
+
+	r.anim.Image = append(r.anim.Image, frame)
+	r.anim.Delay = append(r.anim.Delay, gifFrameDelay)
+}
+
+// fill fills rect in img with the colour at index colorIndex of its palette.
+func fill(img *image.Paletted, rect image.Rectangle, colorIndex uint8) {
+	draw.Draw(img, rect, &image.Uniform{C: img.Palette[colorIndex]}, image.Point{}, draw.Src)
+}
+
+// fillCircle draws a filled circle in img, with the given center and radius, using the colour at index colorIndex of
+// its palette.
+func fillCircle(img *image.Paletted, center image.Point, radius int, colorIndex uint8) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.SetColorIndex(center.X+x, center.Y+y, colorIndex)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/simulation"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_GIFRecorder(t *testing.T) {
+	// Bar --- Foo --- Baz
+	world := worldmap.World{
+		"Foo": worldmap.Roads{
+			worldmap.Direction_West: "Bar",
+			worldmap.Direction_East: "Baz",
+		},
+		"Bar": worldmap.Roads{
+			worldmap.Direction_East: "Foo",
+		},
+		"Baz": worldmap.Roads{
+			worldmap.Direction_West: "Foo",
+		},
+	}
+
+	layout, err := worldmap.NewLayout(world)
+	assert.Nil(t, err)
+
+	recorder := NewGIFRecorder(world, layout)
+	events := []simulation.Event{
+		{Type: simulation.EventType_AlienPlaced, Alien: "alien 0", City: "Bar"},
+		{Type: simulation.EventType_AlienPlaced, Alien: "alien 1", City: "Baz"},
+		{Type: simulation.EventType_AlienMoved, Iteration: 1, Alien: "alien 0", From: "Bar", To: "Foo"},
+		{Type: simulation.EventType_AlienMoved, Iteration: 1, Alien: "alien 1", From: "Baz", To: "Foo"},
+		{Type: simulation.EventType_CityDestroyed, Iteration: 1, City: "Foo", Aliens: []string{"alien 0", "alien 1"}},
+		{Type: simulation.EventType_SimulationEnded, Iteration: 1, Reason: simulation.TerminationReason_AllAliensDestroyed},
+	}
+	for _, e := range events {
+		recorder.Notify(e)
+	}
+
+	out := &bytes.Buffer{}
+	err = recorder.Encode(out)
+	assert.Nil(t, err)
+
+	anim, err := gif.DecodeAll(out)
+	assert.Nil(t, err)
+
+	// one frame for the initial placement and one for the first iteration
+	assert.Len(t, anim.Image, 2)
+	for _, frame := range anim.Image {
+		assert.Equal(t, image.Rect(0, 0, 3*gifCellSize, gifCellSize), frame.Bounds())
+	}
+
+	center := func(x int) (int, int) {
+		return x*gifCellSize + gifCellSize/2, gifCellSize / 2
+	}
+	corner := func(x int) (int, int) {
+		return x*gifCellSize + gifCityInset, gifCityInset
+	}
+
+	// initial frame: aliens at Bar and Baz, Foo is intact
+	initial := anim.Image[0]
+	assert.Equal(t, uint8(firstAlienColorIndex), initial.ColorIndexAt(center(0)))
+	assert.Equal(t, uint8(cityColorIndex), initial.ColorIndexAt(center(1)))
+	assert.Equal(t, uint8(firstAlienColorIndex+1), initial.ColorIndexAt(center(2)))
+	assert.Equal(t, uint8(roadColorIndex), initial.ColorIndexAt(gifCellSize, gifCellSize/2))
+
+	// final frame: Foo is a crater, aliens and roads are gone
+	final := anim.Image[1]
+	assert.Equal(t, uint8(cityColorIndex), final.ColorIndexAt(center(0)))
+	assert.Equal(t, uint8(craterColorIndex), final.ColorIndexAt(center(1)))
+	assert.Equal(t, uint8(craterColorIndex), final.ColorIndexAt(corner(1)))
+	assert.Equal(t, uint8(cityColorIndex), final.ColorIndexAt(center(2)))
+	assert.Equal(t, uint8(backgroundColorIndex), final.ColorIndexAt(gifCellSize, gifCellSize/2))
+
+	// the recorder works on its own copy of the world
+	assert.Contains(t, world, "Foo")
+}
+
+func Test_GIFRecorder_trappedAliens(t *testing.T) {
+	// Bar     Foo     Baz
+	world := worldmap.World{
+		"Foo": worldmap.Roads{},
+		"Bar": worldmap.Roads{},
+		"Baz": worldmap.Roads{},
+	}
+
+	layout := worldmap.Layout{"Bar": {X: 0, Y: 0}, "Foo": {X: 1, Y: 0}, "Baz": {X: 2, Y: 0}}
+
+	recorder := NewGIFRecorder(world, layout)
+	events := []simulation.Event{
+		{Type: simulation.EventType_AlienPlaced, Alien: "alien 0", City: "Bar"},
+		{Type: simulation.EventType_AlienPlaced, Alien: "alien 1", City: "Baz"},
+		{Type: simulation.EventType_AlienStranded, Iteration: 1, Alien: "alien 0", City: "Bar"},
+		{Type: simulation.EventType_AlienDugOut, Iteration: 1, Alien: "alien 1", From: "Baz", To: "Foo"},
+		{Type: simulation.EventType_SimulationEnded, Iteration: 1, Reason: simulation.TerminationReason_MaxIterationsReached},
+	}
+	for _, e := range events {
+		recorder.Notify(e)
+	}
+
+	out := &bytes.Buffer{}
+	err := recorder.Encode(out)
+	assert.Nil(t, err)
+
+	anim, err := gif.DecodeAll(out)
+	assert.Nil(t, err)
+	assert.Len(t, anim.Image, 2)
+
+	center := func(x int) (int, int) {
+		return x*gifCellSize + gifCellSize/2, gifCellSize / 2
+	}
+
+	// final frame: the stranded alien is gone and the other one has emerged in Foo
+	final := anim.Image[1]
+	assert.Equal(t, uint8(cityColorIndex), final.ColorIndexAt(center(0)))
+	assert.Equal(t, uint8(firstAlienColorIndex+1), final.ColorIndexAt(center(1)))
+	assert.Equal(t, uint8(cityColorIndex), final.ColorIndexAt(center(2)))
+}
@@ -0,0 +1,260 @@
+package simulation
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// BattleResolver decides the outcome of battles, fought when two or more aliens meet in a city, and of fights between
+// aliens crossing each other on a road under CrossingRule_DestroyAliens.
+type BattleResolver interface {
+	// Resolve returns the outcome of a battle between fighters in city, or on a road if city is empty. Whether the
+	// city is destroyed is ignored for fights on roads. Every random decision must be taken using rng.
+	Resolve(city string, fighters []Fighter, rng *rand.Rand) BattleOutcome
+}
+
+// Fighter is an alien taking part in a battle, with its attributes as they are when the battle starts.
+type Fighter struct {
+	Name string
+	aliens.Alien
+}
+
+// BattleOutcome is the outcome of a battle. Aliens that fought in it and are not among Survivors are destroyed, and
+// survivors lose the health points in Damage. Survivors of battles that destroy the city are left trapped in its
+// ruins.
+type BattleOutcome struct {
+	Survivors     []string
+	Damage        map[string]int
+	CityDestroyed bool
+}
+
+// Battle records a battle and its outcome.
+type Battle struct {
+	City      string
+	Iteration int
+	Aliens    []string
+	Outcome   BattleOutcome
+}
+
+// Battle rule names accepted by NewBattleResolver.
+const (
+	BattleRuleName_Damage            = "damage"
+	BattleRuleName_MutualDestruction = "mutual-destruction"
+	BattleRuleName_LastAlienStanding = "last-alien-standing"
+	BattleRuleName_Strength          = "strength"
+	BattleRuleName_Defences          = "defences"
+)
+
+// BattleRuleNames lists the names of the built-in battle rules.
+var BattleRuleNames = []string{
+	BattleRuleName_Damage,
+	BattleRuleName_MutualDestruction,
+	BattleRuleName_LastAlienStanding,
+	BattleRuleName_Strength,
+	BattleRuleName_Defences,
+}
+
+// BattleOptions holds the parameters of the built-in battle rules created by NewBattleResolver. Each rule only looks
+// at the ones that make sense for it.
+type BattleOptions struct {
+	// CitySurvives keeps cities standing after battles with a survivor, in the last-alien-standing and strength rules.
+	CitySurvives bool
+	// Defences is the number of battles every city can withstand, in the defences rule.
+	Defences int
+}
+
+// NewBattleResolver creates a new built-in BattleResolver given the name of its rule.
+func NewBattleResolver(name string, opts BattleOptions) (BattleResolver, error) {
+	switch name {
+	case BattleRuleName_Damage:
+		return Damage{}, nil
+	case BattleRuleName_MutualDestruction:
+		return MutualDestruction{}, nil
+	case BattleRuleName_LastAlienStanding:
+		return LastAlienStanding{CitySurvives: opts.CitySurvives}, nil
+	case BattleRuleName_Strength:
+		return Strength{CitySurvives: opts.CitySurvives}, nil
+	case BattleRuleName_Defences:
+		return NewDefences(opts.Defences), nil
+	default:
+		return nil, fmt.Errorf("unknown battle rule %q, it must be one of %s", name, strings.Join(BattleRuleNames, ", "))
+	}
+}
+
+// Damage is a BattleResolver where every alien takes as many health points from every other alien as its strength.
+// Aliens left with health points survive, and the city stands as long as any of them does. It is the rule battles
+// follow by default: aliens with default attributes destroy each other, along with the city.
+type Damage struct{}
+
+// Resolve implements the BattleResolver interface.
+func (Damage) Resolve(_ string, fighters []Fighter, _ *rand.Rand) BattleOutcome {
+	total := 0
+	for _, f := range fighters {
+		total += f.Strength
+	}
+
+	outcome := BattleOutcome{Damage: map[string]int{}}
+	for _, f := range fighters {
+		damage := total - f.Strength
+		if damage < f.Health {
+			outcome.Survivors = append(outcome.Survivors, f.Name)
+			outcome.Damage[f.Name] = damage
+		}
+	}
+	outcome.CityDestroyed = len(outcome.Survivors) == 0
+
+	return outcome
+}
+
+// MutualDestruction is a BattleResolver where aliens destroy each other, along with the city, no matter their health.
+type MutualDestruction struct{}
+
+// Resolve implements the BattleResolver interface.
+func (MutualDestruction) Resolve(_ string, _ []Fighter, _ *rand.Rand) BattleOutcome {
+	return BattleOutcome{CityDestroyed: true}
+}
+
+// LastAlienStanding is a BattleResolver where a random alien survives the battle. The city is destroyed, unless
+// CitySurvives is set.
+type LastAlienStanding struct {
+	CitySurvives bool
+}
+
+// Resolve implements the BattleResolver interface.
+func (r LastAlienStanding) Resolve(_ string, fighters []Fighter, rng *rand.Rand) BattleOutcome {
+	winner := fighters[rng.Intn(len(fighters))]
+
+	return BattleOutcome{Survivors: []string{winner.Name}, CityDestroyed: !r.CitySurvives}
+}
+
+// Strength is a BattleResolver where a random alien survives the battle, chosen with a probability proportional to its
+// strength, or with the same probability as the rest if none of them has any. The city is destroyed, unless
+// CitySurvives is set.
+type Strength struct {
+	CitySurvives bool
+}
+
+// Resolve implements the BattleResolver interface.
+func (r Strength) Resolve(_ string, fighters []Fighter, rng *rand.Rand) BattleOutcome {
+	total := 0
+	for _, f := range fighters {
+		total += f.Strength
+	}
+
+	if total <= 0 {
+		return BattleOutcome{Survivors: []string{fighters[rng.Intn(len(fighters))].Name}, CityDestroyed: !r.CitySurvives}
+	}
+
+	winner := fighters[len(fighters)-1].Name
+	pick := rng.Intn(total)
+	for _, f := range fighters {
+		if pick < f.Strength {
+			winner = f.Name
+			break
+		}
+		pick -= f.Strength
+	}
+
+	return BattleOutcome{Survivors: []string{winner}, CityDestroyed: !r.CitySurvives}
+}
+
+// Defences is a BattleResolver where every city can withstand a number of battles: aliens that attack a city with
+// defences left are destroyed and the city survives, using up one of its defences. Once a city has no defences left,
+// aliens destroy each other along with the city. Roads have no defences, so fights on them always end in mutual
+// destruction. Defences keeps track of the defences left in every city, so a new one is needed for every simulation.
+type Defences struct {
+	perCity int
+	used    map[string]int
+}
+
+// NewDefences creates a new Defences rule where every city can withstand perCity battles.
+func NewDefences(perCity int) *Defences {
+	return &Defences{perCity: perCity, used: map[string]int{}}
+}
+
+// Resolve implements the BattleResolver interface.
+func (r *Defences) Resolve(city string, _ []Fighter, _ *rand.Rand) BattleOutcome {
+	if city != "" && r.used[city] < r.perCity {
+		r.used[city]++
+		return BattleOutcome{}
+	}
+
+	return BattleOutcome{CityDestroyed: true}
+}
+
+// fight resolves the battles in the cities in visitedCities with more than one alien in them. Aliens that don't
+// survive a battle are destroyed, and so is the city if the outcome says so.
+func (inv *invasion) fight(visitedCities map[worldmap.CityID][]int) {
+	for _, city := range battlefields(inv.g, visitedCities) {
+		cityName := inv.g.name(city)
+		attackers := make([]string, 0, len(visitedCities[city]))
+		fighters := make([]Fighter, 0, len(visitedCities[city]))
+		for _, i := range visitedCities[city] {
+			attackers = append(attackers, inv.names[i])
+			fighters = append(fighters, Fighter{Name: inv.names[i], Alien: inv.attrs[i]})
+		}
+
+		inv.notify(Event{Type: EventType_Battle, Iteration: inv.iteration, City: cityName, Aliens: attackers})
+
+		outcome := inv.resolver.Resolve(cityName, fighters, inv.rng)
+		inv.battles = append(inv.battles, Battle{
+			City:      cityName,
+			Iteration: inv.iteration,
+			Aliens:    attackers,
+			Outcome:   outcome,
+		})
+		inv.encounters.stale = true
+
+		if outcome.CityDestroyed {
+			inv.destroyCity(city, attackers)
+		} else {
+			inv.notify(Event{Type: EventType_CityDefended, Iteration: inv.iteration, City: cityName, Aliens: attackers})
+		}
+
+		survivors := make(map[string]bool, len(outcome.Survivors))
+		for _, a := range outcome.Survivors {
+			survivors[a] = true
+		}
+		for _, i := range visitedCities[city] {
+			if survivors[inv.names[i]] {
+				inv.attrs[i].Health -= outcome.Damage[inv.names[i]]
+				inv.notify(Event{
+					Type:      EventType_AlienSurvived,
+					Iteration: inv.iteration,
+					Alien:     inv.names[i],
+					City:      cityName,
+					Health:    inv.attrs[i].Health,
+				})
+				continue
+			}
+
+			inv.alive[i] = false
+			inv.numAlive--
+		}
+	}
+}
+
+// destroyCity destroys city, where attackers fought, and the roads leading to and from it.
+func (inv *invasion) destroyCity(city worldmap.CityID, attackers []string) {
+	cityName := inv.g.name(city)
+	for slot, dest := range inv.g.neighbours(city) {
+		if dest != worldmap.NoCity {
+			inv.notify(Event{
+				Type:      EventType_RoadRemoved,
+				Iteration: inv.iteration,
+				From:      cityName,
+				To:        inv.g.name(dest),
+				Direction: worldmap.Directions[slot],
+			})
+		}
+	}
+
+	inv.g.destroy(city)
+	inv.destroyed = append(inv.destroyed, DestroyedCity{City: cityName, Iteration: inv.iteration, Attackers: attackers})
+
+	inv.notify(Event{Type: EventType_CityDestroyed, Iteration: inv.iteration, City: cityName, Aliens: attackers})
+}
@@ -0,0 +1,158 @@
+package simulation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_NewBattleResolver(t *testing.T) {
+	for _, name := range BattleRuleNames {
+		resolver, err := NewBattleResolver(name, BattleOptions{})
+		assert.NoError(t, err)
+		assert.NotNil(t, resolver)
+	}
+
+	_, err := NewBattleResolver("truce", BattleOptions{})
+	assert.Error(t, err)
+}
+
+func Test_BattleResolvers(t *testing.T) {
+	attackers := []string{"alien 0", "alien 1", "alien 2"}
+	fighters := []Fighter{}
+	for _, a := range attackers {
+		fighters = append(fighters, Fighter{Name: a, Alien: aliens.Alien{}.WithDefaults()})
+	}
+	rng := rand.New(rand.NewSource(1))
+
+	outcome := Damage{}.Resolve("Foo", fighters, rng)
+	assert.Empty(t, outcome.Survivors)
+	assert.True(t, outcome.CityDestroyed)
+
+	// alien 0 takes 3 health points, as many as the strength of each of the others, and is left with 1
+	tough := []Fighter{fighters[0], fighters[1], fighters[2]}
+	tough[0].Health = 4
+	tough[2].Strength = 2
+	outcome = Damage{}.Resolve("Foo", tough, rng)
+	assert.Equal(t, BattleOutcome{Survivors: []string{"alien 0"}, Damage: map[string]int{"alien 0": 3}}, outcome)
+
+	outcome = MutualDestruction{}.Resolve("Foo", fighters, rng)
+	assert.Equal(t, BattleOutcome{CityDestroyed: true}, outcome)
+
+	outcome = LastAlienStanding{}.Resolve("Foo", fighters, rng)
+	assert.Len(t, outcome.Survivors, 1)
+	assert.Contains(t, attackers, outcome.Survivors[0])
+	assert.True(t, outcome.CityDestroyed)
+
+	outcome = LastAlienStanding{CitySurvives: true}.Resolve("Foo", fighters, rng)
+	assert.False(t, outcome.CityDestroyed)
+
+	// aliens with no strength never win
+	weak := []Fighter{fighters[0], fighters[1], fighters[2]}
+	weak[0].Strength, weak[2].Strength = 0, 0
+	for i := 0; i < 10; i++ {
+		outcome = Strength{}.Resolve("Foo", weak, rng)
+		assert.Equal(t, []string{"alien 1"}, outcome.Survivors)
+		assert.True(t, outcome.CityDestroyed)
+	}
+
+	// aliens with no strength at all are equally likely to win
+	powerless := []Fighter{fighters[0], fighters[1], fighters[2]}
+	for i := range powerless {
+		powerless[i].Strength = 0
+	}
+	outcome = Strength{}.Resolve("Foo", powerless, rng)
+	assert.Len(t, outcome.Survivors, 1)
+	assert.Contains(t, attackers, outcome.Survivors[0])
+
+	defences := NewDefences(2)
+	assert.Equal(t, BattleOutcome{}, defences.Resolve("Foo", fighters, rng))
+	assert.Equal(t, BattleOutcome{}, defences.Resolve("Foo", fighters, rng))
+	assert.Equal(t, BattleOutcome{CityDestroyed: true}, defences.Resolve("Foo", fighters, rng))
+	assert.Equal(t, BattleOutcome{}, defences.Resolve("Bar", fighters, rng))
+	// roads have no defences
+	assert.Equal(t, BattleOutcome{CityDestroyed: true}, NewDefences(2).Resolve("", fighters, rng))
+}
+
+func Test_Run_battleResolver(t *testing.T) {
+	// Bar --- Foo --- Baz
+	newWorld := func() worldmap.World {
+		return worldmap.World{
+			"Foo": worldmap.Roads{worldmap.Direction_West: "Bar", worldmap.Direction_East: "Baz"},
+			"Bar": worldmap.Roads{worldmap.Direction_East: "Foo"},
+			"Baz": worldmap.Roads{worldmap.Direction_West: "Foo"},
+		}
+	}
+
+	t.Run("survivor", func(t *testing.T) {
+		alienTracker := aliens.Tracker{"alien 0": {City: "Bar"}, "alien 1": {City: "Baz"}}
+		events := []Event{}
+		result := Run(newWorld(), alienTracker, 1, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+			events = append(events, e)
+		}), Options{BattleResolver: LastAlienStanding{CitySurvives: true}})
+
+		assert.Len(t, result.SurvivingAliens, 1)
+		assert.Empty(t, result.DestroyedCities)
+		if assert.Len(t, result.Battles, 1) {
+			battle := result.Battles[0]
+			assert.Equal(t, "Foo", battle.City)
+			assert.Equal(t, 1, battle.Iteration)
+			assert.Equal(t, []string{"alien 0", "alien 1"}, battle.Aliens)
+			assert.Equal(t, result.SurvivingAliens.Names(), battle.Outcome.Survivors)
+		}
+		assert.Contains(t, events, Event{
+			Type:      EventType_CityDefended,
+			Iteration: 1,
+			City:      "Foo",
+			Aliens:    []string{"alien 0", "alien 1"},
+		})
+		assert.Contains(t, events, Event{
+			Type:      EventType_AlienSurvived,
+			Iteration: 1,
+			Alien:     result.Battles[0].Outcome.Survivors[0],
+			City:      "Foo",
+			Health:    1,
+		})
+		assert.Equal(t, newWorld(), result.World)
+	})
+
+	t.Run("damage", func(t *testing.T) {
+		alienTracker := aliens.Tracker{"alien 0": {City: "Bar", Health: 3}, "alien 1": {City: "Baz"}}
+		events := []Event{}
+		result := Run(newWorld(), alienTracker, 1, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+			events = append(events, e)
+		}), Options{})
+
+		// alien 0 takes a single health point from alien 1, which is destroyed, and Foo is left standing
+		assert.Equal(t, aliens.Tracker{
+			"alien 0": {City: "Foo", Health: 2, Strength: 1, Speed: 1, Moves: 1},
+		}, result.SurvivingAliens)
+		assert.Empty(t, result.DestroyedCities)
+		assert.Contains(t, events, Event{
+			Type:      EventType_AlienSurvived,
+			Iteration: 1,
+			Alien:     "alien 0",
+			City:      "Foo",
+			Health:    2,
+		})
+		assert.Equal(t, newWorld(), result.World)
+	})
+
+	t.Run("survivor in the ruins", func(t *testing.T) {
+		alienTracker := aliens.Tracker{"alien 0": {City: "Bar"}, "alien 1": {City: "Baz"}}
+		result := Run(newWorld(), alienTracker, 10, rand.New(rand.NewSource(1)), nil, Options{
+			BattleResolver: LastAlienStanding{},
+		})
+
+		// the survivor is trapped in what is left of Foo
+		assert.Equal(t, TerminationReason_AllAliensTrapped, result.Reason)
+		assert.Equal(t, 1, result.Iterations)
+		assert.Len(t, result.DestroyedCities, 1)
+		for _, alien := range result.SurvivingAliens {
+			assert.Equal(t, "Foo", alien.City)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// CrossingRule decides what happens when two aliens swap cities along the same road in the same iteration, crossing
+// each other on the way.
+type CrossingRule string
+
+const (
+	// CrossingRule_Ignore lets aliens pass through each other without a fight.
+	CrossingRule_Ignore CrossingRule = "ignore"
+	// CrossingRule_DestroyRoad makes aliens fight on the road, destroying it. Both aliens make it to their destination.
+	CrossingRule_DestroyRoad CrossingRule = "destroy-road"
+	// CrossingRule_DestroyAliens makes aliens fight on the road as the BattleResolver of the simulation says. Aliens
+	// that don't survive the fight are destroyed, and survivors make it to their destination. The road is left untouched.
+	CrossingRule_DestroyAliens CrossingRule = "destroy-aliens"
+)
+
+// CrossingRules lists every rule for aliens crossing each other.
+var CrossingRules = []CrossingRule{CrossingRule_Ignore, CrossingRule_DestroyRoad, CrossingRule_DestroyAliens}
+
+// ParseCrossingRule returns the rule for aliens crossing each other with the given name.
+func ParseCrossingRule(name string) (CrossingRule, error) {
+	for _, rule := range CrossingRules {
+		if string(rule) == name {
+			return rule, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown rule %q, it must be one of %q", name, CrossingRules)
+}
+
+// RoadCrossing records two aliens crossing each other on a road. From, To and Direction describe the road as taken by
+// the first of them.
+type RoadCrossing struct {
+	From      string
+	To        string
+	Direction worldmap.Direction
+	Iteration int
+	Aliens    []string
+}
+
+// cross finds the aliens in moving that crossed each other while moving to visitedCities in the last step and resolves
+// their fights as the crossing rule says. Crossings are processed in alphabetical order of the first alien involved.
+func (inv *invasion) cross(visitedCities map[worldmap.CityID][]int, moving []bool) {
+	if inv.from == nil {
+		return
+	}
+
+	type road struct{ from, to worldmap.CityID }
+	taken := map[road]int{}
+	for i := range inv.names {
+		if !moving[i] || inv.m.slots[i] < 0 {
+			continue
+		}
+
+		r := road{inv.from[i], inv.positions[i]}
+		if _, ok := taken[r]; !ok {
+			taken[r] = i
+		}
+	}
+
+	for i := range inv.names {
+		if !moving[i] || !inv.alive[i] || inv.m.slots[i] < 0 {
+			continue
+		}
+
+		j, ok := taken[road{inv.positions[i], inv.from[i]}]
+		if !ok || j < i || !inv.alive[j] {
+			continue
+		}
+
+		crossing := RoadCrossing{
+			From:      inv.g.name(inv.from[i]),
+			To:        inv.g.name(inv.positions[i]),
+			Direction: worldmap.Directions[inv.m.slots[i]],
+			Iteration: inv.iteration,
+			Aliens:    []string{inv.names[i], inv.names[j]},
+		}
+		inv.crossings = append(inv.crossings, crossing)
+		inv.encounters.stale = true
+
+		event := Event{
+			Iteration: inv.iteration,
+			Aliens:    crossing.Aliens,
+			From:      crossing.From,
+			To:        crossing.To,
+			Direction: crossing.Direction,
+		}
+		switch inv.opts.CrossingRule {
+		case CrossingRule_DestroyRoad:
+			inv.g.removeRoad(inv.from[i], inv.m.slots[i])
+			event.Type = EventType_RoadDestroyed
+
+		case CrossingRule_DestroyAliens:
+			inv.fightOnRoad(event, []int{i, j}, visitedCities)
+			continue
+		}
+
+		inv.notify(event)
+	}
+}
+
+// fightOnRoad resolves the fight between the aliens in pair, which crossed each other on the road described by event.
+// Aliens that don't survive it are destroyed and removed from visitedCities, and survivors lose the health points the
+// outcome says. Fights on roads can't destroy any city, so the outcome is only looked at for its survivors and damage.
+func (inv *invasion) fightOnRoad(event Event, pair []int, visitedCities map[worldmap.CityID][]int) {
+	fighters := make([]Fighter, 0, len(pair))
+	for _, a := range pair {
+		fighters = append(fighters, Fighter{Name: inv.names[a], Alien: inv.attrs[a]})
+	}
+
+	outcome := inv.resolver.Resolve("", fighters, inv.rng)
+
+	survivors := make(map[string]bool, len(outcome.Survivors))
+	for _, a := range outcome.Survivors {
+		survivors[a] = true
+	}
+
+	event.Type = EventType_AliensDestroyed
+	event.Aliens = []string{}
+	for _, a := range pair {
+		if survivors[inv.names[a]] {
+			continue
+		}
+
+		inv.alive[a] = false
+		inv.numAlive--
+		visitedCities[inv.positions[a]] = without(visitedCities[inv.positions[a]], a)
+		event.Aliens = append(event.Aliens, inv.names[a])
+	}
+	if len(event.Aliens) > 0 {
+		inv.notify(event)
+	}
+
+	for _, a := range pair {
+		if !survivors[inv.names[a]] {
+			continue
+		}
+
+		inv.attrs[a].Health -= outcome.Damage[inv.names[a]]
+		inv.notify(Event{
+			Type:      EventType_AlienSurvived,
+			Iteration: inv.iteration,
+			Alien:     inv.names[a],
+			City:      inv.g.name(inv.positions[a]),
+			From:      event.From,
+			To:        event.To,
+			Direction: event.Direction,
+			Health:    inv.attrs[a].Health,
+		})
+	}
+}
+
+// without returns aliens without alien a, reusing its backing array.
+func without(aliens []int, a int) []int {
+	kept := aliens[:0]
+	for _, i := range aliens {
+		if i != a {
+			kept = append(kept, i)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,157 @@
+package simulation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// crossingTestWorld returns a world where the only two aliens can only swap cities, crossing each other:
+//
+//	Foo --- Bar
+func crossingTestWorld() (worldmap.World, aliens.Tracker) {
+	world := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_East: "Bar"},
+		"Bar": worldmap.Roads{worldmap.Direction_West: "Foo"},
+	}
+	alienTracker := aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Bar"}}
+
+	return world, alienTracker
+}
+
+func Test_Run_crossingRule_ignore(t *testing.T) {
+	world, alienTracker := crossingTestWorld()
+
+	result := Run(world, alienTracker, 10, rand.New(rand.NewSource(1)), nil, Options{CrossingRule: CrossingRule_Ignore})
+
+	// aliens can pass through each other forever, so they never meet
+	assert.Equal(t, TerminationReason_NoEncountersPossible, result.Reason)
+	assert.Empty(t, result.RoadCrossings)
+	assert.Len(t, result.SurvivingAliens, 2)
+}
+
+func Test_Run_crossingRule_destroyRoad(t *testing.T) {
+	world, alienTracker := crossingTestWorld()
+
+	events := []Event{}
+	result := Run(world, alienTracker, 10, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		events = append(events, e)
+	}), Options{CrossingRule: CrossingRule_DestroyRoad})
+
+	expected := Event{
+		Type:      EventType_RoadDestroyed,
+		Iteration: 1,
+		Aliens:    []string{"alien 0", "alien 1"},
+		From:      "Foo",
+		To:        "Bar",
+		Direction: worldmap.Direction_East,
+	}
+	assert.Contains(t, events, expected)
+	assert.Equal(t, []RoadCrossing{{
+		From:      "Foo",
+		To:        "Bar",
+		Direction: worldmap.Direction_East,
+		Iteration: 1,
+		Aliens:    []string{"alien 0", "alien 1"},
+	}}, result.RoadCrossings)
+
+	// both aliens make it to the other end of the road, where they are trapped
+	assert.Equal(t, TerminationReason_AllAliensTrapped, result.Reason)
+	assert.Equal(t, map[string]string{"alien 0": "Bar", "alien 1": "Foo"}, result.SurvivingAliens.Cities())
+	assert.Equal(t, worldmap.World{"Foo": worldmap.Roads{}, "Bar": worldmap.Roads{}}, result.World)
+}
+
+func Test_Run_crossingRule_destroyAliens(t *testing.T) {
+	world, alienTracker := crossingTestWorld()
+
+	events := []Event{}
+	result := Run(world, alienTracker, 10, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		events = append(events, e)
+	}), Options{CrossingRule: CrossingRule_DestroyAliens})
+
+	expected := Event{
+		Type:      EventType_AliensDestroyed,
+		Iteration: 1,
+		Aliens:    []string{"alien 0", "alien 1"},
+		From:      "Foo",
+		To:        "Bar",
+		Direction: worldmap.Direction_East,
+	}
+	assert.Contains(t, events, expected)
+	assert.Len(t, result.RoadCrossings, 1)
+
+	// the road and the cities are left untouched
+	assert.Equal(t, TerminationReason_AllAliensDestroyed, result.Reason)
+	assert.Empty(t, result.SurvivingAliens)
+	assert.Empty(t, result.DestroyedCities)
+	assert.Equal(t, worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_East: "Bar"},
+		"Bar": worldmap.Roads{worldmap.Direction_West: "Foo"},
+	}, result.World)
+}
+
+func Test_Run_crossingRule_destroyAliens_survivors(t *testing.T) {
+	world, _ := crossingTestWorld()
+	// aliens are tough enough to survive the fight on the road
+	alienTracker := aliens.Tracker{"alien 0": {City: "Foo", Health: 3}, "alien 1": {City: "Bar", Health: 3}}
+
+	events := []Event{}
+	result := Run(world, alienTracker, 1, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		events = append(events, e)
+	}), Options{CrossingRule: CrossingRule_DestroyAliens})
+
+	for _, e := range events {
+		assert.NotEqual(t, EventType_AliensDestroyed, e.Type)
+	}
+	assert.Contains(t, events, Event{
+		Type:      EventType_AlienSurvived,
+		Iteration: 1,
+		Alien:     "alien 1",
+		City:      "Foo",
+		From:      "Foo",
+		To:        "Bar",
+		Direction: worldmap.Direction_East,
+		Health:    2,
+	})
+	assert.Len(t, result.RoadCrossings, 1)
+
+	// both aliens make it to the other end of the road, hurt
+	assert.Equal(t, TerminationReason_MaxIterationsReached, result.Reason)
+	assert.Equal(t, map[string]string{"alien 0": "Bar", "alien 1": "Foo"}, result.SurvivingAliens.Cities())
+	assert.Equal(t, 2, result.SurvivingAliens["alien 0"].Health)
+	assert.Equal(t, 2, result.SurvivingAliens["alien 1"].Health)
+
+	// only the weakest alien is destroyed when the other one can take the hit
+	world, _ = crossingTestWorld()
+	alienTracker = aliens.Tracker{"alien 0": {City: "Foo", Health: 3}, "alien 1": {City: "Bar"}}
+
+	events = []Event{}
+	result = Run(world, alienTracker, 1, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		events = append(events, e)
+	}), Options{CrossingRule: CrossingRule_DestroyAliens})
+
+	assert.Contains(t, events, Event{
+		Type:      EventType_AliensDestroyed,
+		Iteration: 1,
+		Aliens:    []string{"alien 1"},
+		From:      "Foo",
+		To:        "Bar",
+		Direction: worldmap.Direction_East,
+	})
+	assert.Equal(t, map[string]string{"alien 0": "Bar"}, result.SurvivingAliens.Cities())
+	assert.Equal(t, 2, result.SurvivingAliens["alien 0"].Health)
+}
+
+func Test_ParseCrossingRule(t *testing.T) {
+	for _, rule := range CrossingRules {
+		got, err := ParseCrossingRule(string(rule))
+		assert.NoError(t, err)
+		assert.Equal(t, rule, got)
+	}
+
+	_, err := ParseCrossingRule("tunnel")
+	assert.Error(t, err)
+}
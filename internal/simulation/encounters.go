@@ -0,0 +1,131 @@
+package simulation
+
+import (
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// encounters finds out whether the aliens of an invasion can still meet each other. Aliens can only meet if they are in
+// the same connected group of cities. Moreover, when every alien takes a road in every step, cities in a bipartite
+// group can be coloured so that every road joins cities of different colours, and aliens change colour at the same
+// time in every step. As long as all of them take either an odd or an even number of steps per iteration, two aliens
+// that stand on cities of different colours can then never meet.
+// Cities and roads are only destroyed by aliens meeting, so once no encounter is possible the invasion is over for
+// good. Aliens that cross each other on a road meet halfway between cities of different colours, though, so colours
+// are of no use when they fight there.
+type encounters struct {
+	// parity tells whether the colours of cities can be relied upon, which is only the case when every alien always
+	// takes a road if there is one and the speeds of all of them are either odd or even
+	parity bool
+	// stale tells whether the world has changed since encounters were last checked
+	stale    bool
+	possible bool
+}
+
+// newEncounters returns the encounters analysis for the aliens moved by m, with the given attributes, under the rules
+// in opts.
+func newEncounters(m *mover, attrs []aliens.Alien, opts Options) *encounters {
+	crossingsIgnored := opts.CrossingRule == "" || opts.CrossingRule == CrossingRule_Ignore
+	e := &encounters{parity: crossingsIgnored, stale: true}
+	for _, s := range m.strategies {
+		if !alwaysMoves(s) {
+			e.parity = false
+			break
+		}
+	}
+	for _, a := range attrs {
+		if a.Speed%2 != attrs[0].Speed%2 {
+			e.parity = false
+			break
+		}
+	}
+
+	return e
+}
+
+// alwaysMoves reports whether aliens following s always take a road when there is any.
+func alwaysMoves(s aliens.Strategy) bool {
+	switch s.(type) {
+	case aliens.Uniform, *aliens.NonBacktracking, aliens.Hunter, aliens.Evader:
+		return true
+	default:
+		return false
+	}
+}
+
+// encountersPossible reports whether any two aliens can still meet. Only invasions of a worldmap.Compact are analysed,
+// as the cities of other terrains may be endless. Aliens that dig their way out can emerge anywhere, so nothing is
+// ruled out under TrappedPolicy_DigOut either.
+func (inv *invasion) encountersPossible() bool {
+	if inv.numAlive <= 1 {
+		return false
+	}
+
+	g, isCompact := inv.g.(compactGraph)
+	if !isCompact || inv.opts.TrappedPolicy == TrappedPolicy_DigOut {
+		return true
+	}
+
+	// the world only changes when aliens fight, so the outcome holds until they do
+	if inv.encounters.stale {
+		inv.encounters.stale = false
+		inv.encounters.possible = inv.encounters.check(g, inv.positions, inv.alive)
+	}
+
+	return inv.encounters.possible
+}
+
+// check explores the groups of cities where aliens are, colouring them as it goes, and reports whether any two aliens
+// can meet. It stops as soon as it finds two aliens that can, so that crowded invasions are not slowed down.
+func (e *encounters) check(g compactGraph, positions []worldmap.CityID, alive []bool) bool {
+	occupied := map[worldmap.CityID]int{}
+	for i, pos := range positions {
+		// aliens placed in cities that are not part of the world can't meet anyone
+		if alive[i] && pos != worldmap.NoCity {
+			occupied[pos]++
+		}
+	}
+
+	// colours holds 0 for cities not explored yet, and 1 or 2 for the rest
+	colours := make([]int8, g.Len())
+	for origin := range occupied {
+		if colours[origin] != 0 {
+			continue
+		}
+
+		var found [3]int
+		bipartite := true
+		canMeet := func() bool {
+			if e.parity && bipartite {
+				return found[1] > 1 || found[2] > 1
+			}
+
+			return found[1]+found[2] > 1
+		}
+
+		colours[origin] = 1
+		queue := []worldmap.CityID{origin}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			found[colours[current]] += occupied[current]
+
+			for _, dest := range g.neighbours(current) {
+				switch {
+				case dest == worldmap.NoCity:
+				case colours[dest] == 0:
+					colours[dest] = 3 - colours[current]
+					queue = append(queue, dest)
+				case colours[dest] == colours[current]:
+					bipartite = false
+				}
+			}
+
+			if canMeet() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package simulation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_encountersPossible(t *testing.T) {
+	// Foo --- Bar --- Baz     Qux --- Quux
+	line := worldmap.World{
+		"Foo":  worldmap.Roads{worldmap.Direction_East: "Bar"},
+		"Bar":  worldmap.Roads{worldmap.Direction_West: "Foo", worldmap.Direction_East: "Baz"},
+		"Baz":  worldmap.Roads{worldmap.Direction_West: "Bar"},
+		"Qux":  worldmap.Roads{worldmap.Direction_East: "Quux"},
+		"Quux": worldmap.Roads{worldmap.Direction_West: "Qux"},
+	}
+	// Foo --- Bar
+	//  |     /
+	// Baz --
+	triangle := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_East: "Bar", worldmap.Direction_South: "Baz"},
+		"Bar": worldmap.Roads{worldmap.Direction_West: "Foo", worldmap.Direction_South: "Baz"},
+		"Baz": worldmap.Roads{worldmap.Direction_North: "Foo", worldmap.Direction_East: "Bar"},
+	}
+
+	testCases := []struct {
+		name     string
+		world    worldmap.World
+		aliens   aliens.Tracker
+		opts     Options
+		possible bool
+	}{
+		{
+			name:     "single alien",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}},
+			possible: false,
+		},
+		{
+			name:     "different groups",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Bar"}, "alien 1": {City: "Qux"}},
+			possible: false,
+		},
+		{
+			name:     "different colours",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Bar"}, "alien 2": {City: "Qux"}},
+			possible: false,
+		},
+		{
+			name:     "same colour",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Baz"}},
+			possible: true,
+		},
+		{
+			name:     "different colours with aliens that may stay",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Bar"}},
+			opts:     Options{Strategies: map[string]aliens.Strategy{"alien 0": aliens.Lazy{StayProbability: 0.5}}},
+			possible: true,
+		},
+		{
+			name:     "different colours with aliens that always move",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Bar"}},
+			opts:     Options{Strategy: aliens.Hunter{SightRange: 10}},
+			possible: false,
+		},
+		{
+			name:     "odd cycle",
+			world:    triangle,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Bar"}},
+			possible: true,
+		},
+		{
+			name:     "alien outside the world",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Nowhere"}},
+			possible: false,
+		},
+		{
+			name:     "aliens inside and outside the world",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Baz"}, "alien 2": {City: "Nowhere"}},
+			possible: true,
+		},
+		{
+			name:     "digging out",
+			world:    line,
+			aliens:   aliens.Tracker{"alien 0": {City: "Bar"}, "alien 1": {City: "Qux"}},
+			opts:     Options{TrappedPolicy: TrappedPolicy_DigOut},
+			possible: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inv := newInvasion(tc.world, tc.aliens, rand.New(rand.NewSource(1)), nil, tc.opts)
+
+			assert.Equal(t, tc.possible, inv.encountersPossible())
+		})
+	}
+}
+
+func Test_Run_noEncountersPossible(t *testing.T) {
+	// Foo --- Bar --- Baz     Qux --- Quux
+	world := worldmap.World{
+		"Foo":  worldmap.Roads{worldmap.Direction_East: "Bar"},
+		"Bar":  worldmap.Roads{worldmap.Direction_West: "Foo", worldmap.Direction_East: "Baz"},
+		"Baz":  worldmap.Roads{worldmap.Direction_West: "Bar"},
+		"Qux":  worldmap.Roads{worldmap.Direction_East: "Quux"},
+		"Quux": worldmap.Roads{worldmap.Direction_West: "Qux"},
+	}
+	// the aliens in Foo and Baz meet in Bar, leaving the alien in Qux on its own
+	alienTracker := aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Baz"}, "alien 2": {City: "Qux"}}
+
+	result := Run(world, alienTracker, 1000, rand.New(rand.NewSource(1)), nil, Options{})
+
+	assert.Equal(t, TerminationReason_NoEncountersPossible, result.Reason)
+	assert.Equal(t, 1, result.Iterations)
+	assert.Equal(t, []string{"alien 2"}, result.SurvivingAliens.Names())
+}
@@ -0,0 +1,110 @@
+package simulation
+
+import (
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// EventType identifies the kind of thing that happened during a simulation.
+type EventType string
+
+const (
+	// EventType_AlienPlaced is emitted once per alien before the first iteration, with the city the alien starts at.
+	EventType_AlienPlaced EventType = "alien_placed"
+	// EventType_AlienMoved is emitted every time an alien takes a road to a neighbouring city, so fast aliens can move
+	// several times in the same iteration.
+	EventType_AlienMoved EventType = "alien_moved"
+	// EventType_AlienTrapped is emitted when an alien is found in a city with no roads left, either when it is placed
+	// or at the end of the iteration in which its last road was removed.
+	EventType_AlienTrapped EventType = "alien_trapped"
+	// EventType_AlienStranded is emitted when a trapped alien is removed from the simulation.
+	EventType_AlienStranded EventType = "alien_stranded"
+	// EventType_AlienDugOut is emitted when a trapped alien digs its way out to another city.
+	EventType_AlienDugOut EventType = "alien_dug_out"
+	// EventType_Battle is emitted when two or more aliens meet in the same city.
+	EventType_Battle EventType = "battle"
+	// EventType_CityDestroyed is emitted when a city is destroyed as a result of a battle.
+	EventType_CityDestroyed EventType = "city_destroyed"
+	// EventType_CityDefended is emitted when a city survives a battle.
+	EventType_CityDefended EventType = "city_defended"
+	// EventType_AlienSurvived is emitted for every alien that survives a battle, after the city is destroyed or
+	// defended, and for every alien that survives a fight on a road.
+	EventType_AlienSurvived EventType = "alien_survived"
+	// EventType_RoadRemoved is emitted for every road that disappears along with a destroyed city.
+	EventType_RoadRemoved EventType = "road_removed"
+	// EventType_RoadDestroyed is emitted when two aliens cross each other on a road and destroy it, under
+	// CrossingRule_DestroyRoad.
+	EventType_RoadDestroyed EventType = "road_destroyed"
+	// EventType_AliensDestroyed is emitted when two aliens cross each other on a road and any of them is destroyed in
+	// the fight, under CrossingRule_DestroyAliens.
+	EventType_AliensDestroyed EventType = "aliens_destroyed"
+	// EventType_SimulationEnded is emitted once, after the last iteration.
+	EventType_SimulationEnded EventType = "simulation_ended"
+)
+
+// TerminationReason explains why a simulation stopped.
+type TerminationReason string
+
+const (
+	TerminationReason_AllAliensDestroyed   TerminationReason = "all_aliens_destroyed"
+	TerminationReason_MaxIterationsReached TerminationReason = "max_iterations_reached"
+	TerminationReason_AllAliensTrapped     TerminationReason = "all_aliens_trapped"
+	TerminationReason_NoEncountersPossible TerminationReason = "no_encounters_possible"
+)
+
+// Event describes something that happened during a simulation. Only the fields that make sense for its Type are set:
+//
+//   - alien_placed: Alien and City.
+//   - alien_moved: Alien, From, To and Direction.
+//   - alien_trapped: Alien and City.
+//   - alien_stranded: Alien and City.
+//   - alien_dug_out: Alien, From and To.
+//   - battle: City and Aliens, the aliens taking part in it.
+//   - city_destroyed: City and Aliens, the aliens that destroyed it.
+//   - city_defended: City and Aliens, the aliens that attacked it.
+//   - alien_survived: Alien, City, the city where the battle took place, and Health, the health points it has left.
+//     Aliens that survive a fight on a road also get From, To and Direction, as seen by the first alien that took the
+//     road, and City is the city they make it to.
+//   - road_removed: From, To and Direction, as seen from the destroyed city.
+//   - road_destroyed: From, To, Direction and Aliens, as seen by the first alien that took the road.
+//   - aliens_destroyed: From, To and Direction, as seen by the first alien that took the road, and Aliens, the ones
+//     destroyed.
+//   - simulation_ended: Reason, Aliens, the surviving aliens, and World, what the world looks like at the end.
+//
+// Iteration is the (1-based) iteration the event took place in, or 0 for events emitted before the first one.
+type Event struct {
+	Type      EventType          `json:"type"`
+	Iteration int                `json:"iteration"`
+	Alien     string             `json:"alien,omitempty"`
+	Aliens    []string           `json:"aliens,omitempty"`
+	City      string             `json:"city,omitempty"`
+	From      string             `json:"from,omitempty"`
+	To        string             `json:"to,omitempty"`
+	Direction worldmap.Direction `json:"direction,omitempty"`
+	Health    int                `json:"health,omitempty"`
+	Reason    TerminationReason  `json:"reason,omitempty"`
+	World     worldmap.World     `json:"world,omitempty"`
+}
+
+// Observer receives the events produced by a simulation. Events are delivered synchronously and in the order they
+// happen, so implementations should return quickly.
+type Observer interface {
+	Notify(event Event)
+}
+
+// ObserverFunc is an adapter to allow the use of ordinary functions as Observers.
+type ObserverFunc func(event Event)
+
+// Notify calls f(event).
+func (f ObserverFunc) Notify(event Event) {
+	f(event)
+}
+
+// Observers is an Observer that forwards every event to each of the observers it holds, in order.
+type Observers []Observer
+
+// Notify implements the Observer interface.
+func (o Observers) Notify(event Event) {
+	for _, observer := range o {
+		observer.Notify(event)
+	}
+}
@@ -0,0 +1,158 @@
+package simulation
+
+import (
+	"math/rand"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// graph is the view of the world the simulation loop works with, where cities are identified by integers so that
+// moving aliens around doesn't involve looking up city names.
+type graph interface {
+	// id returns the ID of the city with the given name, or worldmap.NoCity if there is no such city.
+	id(city string) worldmap.CityID
+	// name returns the name of the city with the given ID.
+	name(id worldmap.CityID) string
+	// neighbours returns the cities reachable from the city with the given ID, as described in
+	// worldmap.Compact.Neighbours.
+	neighbours(id worldmap.CityID) [4]worldmap.CityID
+	// destroy destroys the city with the given ID.
+	destroy(id worldmap.CityID)
+	// removeRoad destroys the road in the given slot of the neighbours of the city with the given ID, along with the
+	// road back.
+	removeRoad(id worldmap.CityID, slot int)
+	// randomCity returns a random city that has not been destroyed, chosen using rng, or worldmap.NoCity if there is
+	// none.
+	randomCity(rng *rand.Rand) worldmap.CityID
+}
+
+// newGraph returns the graph the simulation of an invasion of world works with. Worlds are converted to
+// worldmap.Compact, which is used as is. Any other worldmap.Terrain is wrapped by a terrainGraph.
+func newGraph(world worldmap.Terrain) graph {
+	switch w := world.(type) {
+	case worldmap.World:
+		return compactGraph{worldmap.NewCompact(w)}
+	case *worldmap.Compact:
+		return compactGraph{w}
+	default:
+		return newTerrainGraph(w)
+	}
+}
+
+// compactGraph is a graph backed by a worldmap.Compact.
+type compactGraph struct {
+	*worldmap.Compact
+}
+
+func (g compactGraph) id(city string) worldmap.CityID {
+	if id, ok := g.ID(city); ok {
+		return id
+	}
+
+	return worldmap.NoCity
+}
+
+func (g compactGraph) name(id worldmap.CityID) string {
+	return g.Name(id)
+}
+
+func (g compactGraph) neighbours(id worldmap.CityID) [4]worldmap.CityID {
+	if id == worldmap.NoCity {
+		return [4]worldmap.CityID{worldmap.NoCity, worldmap.NoCity, worldmap.NoCity, worldmap.NoCity}
+	}
+
+	return g.Neighbours(id)
+}
+
+func (g compactGraph) destroy(id worldmap.CityID) {
+	g.Destroy(id)
+}
+
+func (g compactGraph) removeRoad(id worldmap.CityID, slot int) {
+	g.RemoveRoad(id, slot)
+}
+
+func (g compactGraph) randomCity(rng *rand.Rand) worldmap.CityID {
+	standing := 0
+	for id := worldmap.CityID(0); int(id) < g.Len(); id++ {
+		if !g.IsDestroyed(id) {
+			standing++
+		}
+	}
+
+	if standing == 0 {
+		return worldmap.NoCity
+	}
+
+	pick := rng.Intn(standing)
+	for id := worldmap.CityID(0); int(id) < g.Len(); id++ {
+		if g.IsDestroyed(id) {
+			continue
+		}
+
+		if pick == 0 {
+			return id
+		}
+		pick--
+	}
+
+	return worldmap.NoCity
+}
+
+// terrainGraph is a graph backed by any worldmap.Terrain. Cities get IDs as they are found, in no particular order.
+type terrainGraph struct {
+	terrain worldmap.Terrain
+	ids     map[string]worldmap.CityID
+	names   []string
+}
+
+// newTerrainGraph creates a new terrainGraph backed by terrain.
+func newTerrainGraph(terrain worldmap.Terrain) *terrainGraph {
+	return &terrainGraph{terrain: terrain, ids: map[string]worldmap.CityID{}}
+}
+
+func (g *terrainGraph) id(city string) worldmap.CityID {
+	if id, ok := g.ids[city]; ok {
+		return id
+	}
+
+	id := worldmap.CityID(len(g.names))
+	g.ids[city] = id
+	g.names = append(g.names, city)
+
+	return id
+}
+
+func (g *terrainGraph) name(id worldmap.CityID) string {
+	return g.names[id]
+}
+
+func (g *terrainGraph) neighbours(id worldmap.CityID) [4]worldmap.CityID {
+	neighbours := [4]worldmap.CityID{}
+	roads := g.terrain.Roads(g.names[id])
+	for slot, dir := range worldmap.Directions {
+		neighbours[slot] = worldmap.NoCity
+		if dest, ok := roads[dir]; ok {
+			neighbours[slot] = g.id(dest)
+		}
+	}
+
+	return neighbours
+}
+
+func (g *terrainGraph) destroy(id worldmap.CityID) {
+	g.terrain.DestroyCity(g.names[id])
+}
+
+func (g *terrainGraph) removeRoad(id worldmap.CityID, slot int) {
+	g.terrain.DestroyRoad(g.names[id], worldmap.Directions[slot])
+}
+
+func (g *terrainGraph) randomCity(rng *rand.Rand) worldmap.CityID {
+	cities, err := g.terrain.RandomCities(1, rng)
+	if err != nil {
+		return worldmap.NoCity
+	}
+
+	return g.id(cities[0])
+}
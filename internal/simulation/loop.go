@@ -1,53 +1,307 @@
 package simulation
 
 import (
-	"fmt"
-	"io"
-	"strings"
+	"math/rand"
+	"sort"
 
 	"github.com/volmedo/invasim/internal/aliens"
 	"github.com/volmedo/invasim/internal/worldmap"
 )
 
+// Options tweaks how a simulation is run.
+type Options struct {
+	// Parallelism is the number of goroutines aliens are moved by. Aliens are split into shards of consecutive aliens,
+	// and every shard is moved with its own random stream derived from rng. If 0, shards are moved one after another.
+	// Otherwise, they are spread across Parallelism goroutines. The outcome never depends on the value of Parallelism,
+	// so a simulation run with Parallelism 0 is always identical to the same simulation run with 16. Only picking the
+	// roads aliens take is spread across goroutines, and the rest of every iteration is sequential, so simulations can
+	// only get faster with several CPUs, and by less than the number of goroutines.
+	Parallelism int
+	// Strategy decides where aliens move to. If nil, aliens take any road available to them with the same probability,
+	// like with aliens.Uniform. When any strategy is given, aliens are always moved one after another.
+	Strategy aliens.Strategy
+	// Strategies holds the strategies of the aliens that don't follow Strategy.
+	Strategies map[string]aliens.Strategy
+	// TrappedPolicy decides what happens to aliens trapped in a city with no roads left. The zero value behaves like
+	// TrappedPolicy_Keep.
+	TrappedPolicy TrappedPolicy
+	// DigOutTurns is the number of iterations trapped aliens wait before digging their way out, when TrappedPolicy is
+	// TrappedPolicy_DigOut.
+	DigOutTurns int
+	// CrossingRule decides what happens to aliens that cross each other on a road. The zero value behaves like
+	// CrossingRule_Ignore.
+	CrossingRule CrossingRule
+	// BattleResolver decides the outcome of battles, and of fights on roads under CrossingRule_DestroyAliens. If nil,
+	// aliens damage each other as described in Damage.
+	BattleResolver BattleResolver
+}
+
 // Run runs a new simulation with the given parameters.
 //
 // The simulation is implemented as a loop. In each iteration, aliens move randomly to any of the cities that are
-// reachable from the city they are currently in, one city at a time. When aliens end up in the same city, they
-// unleash their futuristic weapons and destroy each other, along with the city itself and any roads leading into or
-// out of it.
-// The simulation ends when there are no more aliens alive or maxIterations iterations have been executed, whatever
-// happens first.
-// The function accepts an io.Writer where city destruction messages will be printed to make testing for correct output
-// easier.
-func Run(world worldmap.World, alienTracker aliens.Tracker, maxIterations int, out io.Writer) {
-	for i := 0; i < maxIterations && len(alienTracker) > 0; i++ {
-		// move aliens
-		// at this point no city should have more than 1 alien (it would've already been destroyed otherwise)
-		visitedCities := alienTracker.MoveRandomly(world)
-
-		// check if aliens are in the same place using the visited cities view
-		for city, aliens := range visitedCities {
-			if len(aliens) > 1 {
-				world.DestroyCity(city)
-				alienTracker.DestroyAliens(aliens)
-
-				fmt.Fprintf(
-					out,
-					"%s has been destroyed by %s and %s!\n",
-					city, strings.Join(aliens[:len(aliens)-1], ", "), aliens[len(aliens)-1],
-				)
+// reachable from the city they are currently in, one city at a time, taking as many roads as their speed. When aliens
+// end up in the same city, they unleash their futuristic weapons and, unless opts.BattleResolver says otherwise, damage
+// each other. Aliens with no health left are destroyed, and so is the city, along with any roads leading into or out
+// of it, if none of them survives. Aliens that cross each other on a road fight too if opts.CrossingRule says so,
+// before battles in cities.
+// The simulation ends when there are no more aliens alive, no alien can move anymore because all of them are trapped
+// in cities with no roads left, no two aliens can ever meet again, or maxIterations iterations have been executed,
+// whatever happens first. What happens to trapped aliens is decided by opts.TrappedPolicy.
+// Everything that happens during the simulation is reported as an Event to observer, which can be nil if the caller is
+// not interested in them. Once the simulation is over, a summary of the invasion is returned as a Result.
+// Every random decision is taken using rng, and battles are processed in alphabetical order of the cities where they
+// take place, so running the same simulation with the same seed always produces the same sequence of events.
+// world can be any worldmap.Terrain, but the final state of the world is only reported when it is a worldmap.World.
+// opts tweaks how the simulation is run. Its zero value is fine for most simulations.
+func Run(
+	world worldmap.Terrain,
+	alienTracker aliens.Tracker,
+	maxIterations int,
+	rng *rand.Rand,
+	observer Observer,
+	opts Options,
+) Result {
+	inv := newInvasion(world, alienTracker, rng, observer, opts)
+	inv.checkTrapped()
+
+	for inv.iteration < maxIterations && inv.numAlive > 0 && inv.canMove() && inv.encountersPossible() {
+		inv.iteration++
+		inv.digOut()
+		visitedCities := inv.moveAliens()
+		inv.fight(visitedCities)
+		inv.checkTrapped()
+	}
+
+	// check final conditions: either all aliens were destroyed, we reached maxIterations, the ones left can't move or
+	// they can't meet each other anymore
+	reason := TerminationReason_NoEncountersPossible
+	switch {
+	case inv.numAlive == 0 && len(inv.strandedAliens) == 0:
+		reason = TerminationReason_AllAliensDestroyed
+	case inv.iteration >= maxIterations:
+		reason = TerminationReason_MaxIterationsReached
+	case !inv.canMove():
+		reason = TerminationReason_AllAliensTrapped
+	}
+
+	// bring the tracker and the world up to date
+	for i, a := range inv.names {
+		if !inv.alive[i] {
+			delete(alienTracker, a)
+		} else if inv.positions[i] != worldmap.NoCity {
+			alien := inv.attrs[i]
+			alien.City = inv.g.name(inv.positions[i])
+			alienTracker[a] = alien
+		}
+	}
+
+	finalWorld, isWorld := world.(worldmap.World)
+	if isWorld {
+		if inv.opts.CrossingRule == CrossingRule_DestroyRoad {
+			for _, c := range inv.crossings {
+				finalWorld.DestroyRoad(c.From, c.Direction)
 			}
 		}
+		for _, d := range inv.destroyed {
+			finalWorld.DestroyCity(d.City)
+		}
+	}
+
+	inv.notify(Event{
+		Type:      EventType_SimulationEnded,
+		Iteration: inv.iteration,
+		Aliens:    alienTracker.Names(),
+		Reason:    reason,
+		World:     finalWorld,
+	})
+
+	return Result{
+		Iterations:      inv.iteration,
+		Reason:          reason,
+		DestroyedCities: inv.destroyed,
+		Battles:         inv.battles,
+		SurvivingAliens: alienTracker,
+		StrandedAliens:  inv.strandedAliens,
+		RoadCrossings:   inv.crossings,
+		World:           finalWorld,
+	}
+}
+
+// invasion holds the state of a simulation while it runs. Aliens are identified by their index in names, and cities by
+// their ID in g.
+type invasion struct {
+	g         graph
+	names     []string
+	positions []worldmap.CityID
+	// attrs holds the attributes of every alien. Their cities are kept in positions instead.
+	attrs    []aliens.Alien
+	alive    []bool
+	numAlive int
+	// maxSpeed is the number of roads the fastest alien takes in every iteration
+	maxSpeed int
+	// trappedFor holds the number of iterations every alien has been trapped for, or -1 if it is not trapped
+	trappedFor     []int
+	strandedAliens aliens.Tracker
+	destroyed      []DestroyedCity
+	battles        []Battle
+	crossings      []RoadCrossing
+	iteration      int
+	encounters     *encounters
+	// from holds the city every alien moved from in the last iteration, or is nil if crossings are ignored
+	from []worldmap.CityID
+
+	m        *mover
+	resolver BattleResolver
+	rng      *rand.Rand
+	observer Observer
+	opts     Options
+}
+
+// newInvasion sets up a new simulation of an invasion of world by the aliens in alienTracker, reporting that they
+// have been placed in their starting positions.
+func newInvasion(
+	world worldmap.Terrain,
+	alienTracker aliens.Tracker,
+	rng *rand.Rand,
+	observer Observer,
+	opts Options,
+) *invasion {
+	g := newGraph(world)
+	names := alienTracker.Names()
+	inv := &invasion{
+		g:              g,
+		names:          names,
+		positions:      make([]worldmap.CityID, len(names)),
+		attrs:          make([]aliens.Alien, len(names)),
+		alive:          make([]bool, len(names)),
+		numAlive:       len(names),
+		trappedFor:     make([]int, len(names)),
+		strandedAliens: aliens.Tracker{},
+		destroyed:      []DestroyedCity{},
+		battles:        []Battle{},
+		crossings:      []RoadCrossing{},
+		m:              newMover(g, names, opts),
+		resolver:       opts.BattleResolver,
+		rng:            rng,
+		observer:       observer,
+		opts:           opts,
+	}
+
+	if inv.resolver == nil {
+		inv.resolver = Damage{}
+	}
+	if opts.CrossingRule != "" && opts.CrossingRule != CrossingRule_Ignore {
+		inv.from = make([]worldmap.CityID, len(names))
+	}
+
+	for i, a := range names {
+		alien := alienTracker[a]
+		inv.positions[i] = g.id(alien.City)
+		inv.attrs[i] = alien.WithDefaults()
+		inv.alive[i] = true
+		inv.trappedFor[i] = -1
+		if inv.attrs[i].Speed > inv.maxSpeed {
+			inv.maxSpeed = inv.attrs[i].Speed
+		}
+		inv.notify(Event{Type: EventType_AlienPlaced, Alien: a, City: alien.City})
+	}
+	inv.encounters = newEncounters(inv.m, inv.attrs, opts)
+
+	return inv
+}
+
+// notify reports event to the observer, if there is one.
+func (inv *invasion) notify(event Event) {
+	if inv.observer != nil {
+		inv.observer.Notify(event)
+	}
+}
+
+// cityName returns the name of the city alien i is at.
+func (inv *invasion) cityName(i int) string {
+	if inv.positions[i] == worldmap.NoCity {
+		return ""
+	}
+
+	return inv.g.name(inv.positions[i])
+}
+
+// moveAliens moves aliens in alphabetical order, letting them fight on the roads they cross if the crossing rule says
+// so, and returns the cities they end up at. Aliens take a road at a time: in the first step all of them move, and in
+// every step after that only the ones fast enough do.
+func (inv *invasion) moveAliens() map[worldmap.CityID][]int {
+	// at this point no city should have more than 1 alien (it would've already been destroyed otherwise)
+	visitedCities := inv.step(inv.alive)
+	if inv.maxSpeed == 1 {
+		return visitedCities
+	}
+
+	moving := make([]bool, len(inv.names))
+	for s := 1; s < inv.maxSpeed; s++ {
+		for i := range inv.names {
+			moving[i] = inv.alive[i] && inv.attrs[i].Speed > s
+		}
+		inv.step(moving)
 	}
 
-	// check final conditions: either all aliens were destroyed or we reached maxIterations
-	fmt.Fprintf(out, "Simulation finished!\n")
-	if len(alienTracker) == 0 {
-		fmt.Fprintf(out, "All aliens were destroyed!\n")
-	} else {
-		fmt.Fprintf(out, "Max iterations reached, %d alien(s) remaining\n", len(alienTracker))
+	// every alien that is still alive ends up where its last step took it
+	visitedCities = map[worldmap.CityID][]int{}
+	for i := range inv.names {
+		if inv.alive[i] && inv.positions[i] != worldmap.NoCity {
+			visitedCities[inv.positions[i]] = append(visitedCities[inv.positions[i]], i)
+		}
+	}
+
+	return visitedCities
+}
+
+// step moves the aliens in moving along a single road and returns the cities they end up at.
+func (inv *invasion) step(moving []bool) map[worldmap.CityID][]int {
+	visitedCities := inv.m.move(inv.names, inv.positions, moving, inv.alive, inv.rng)
+
+	for i, a := range inv.names {
+		if !moving[i] || inv.m.slots[i] < 0 {
+			continue
+		}
+
+		from, to := inv.positions[i], inv.m.dests[i]
+		inv.positions[i] = to
+		inv.attrs[i].Moves++
+		if inv.from != nil {
+			inv.from[i] = from
+		}
+
+		if inv.observer != nil {
+			inv.notify(Event{
+				Type:      EventType_AlienMoved,
+				Iteration: inv.iteration,
+				Alien:     a,
+				From:      inv.g.name(from),
+				To:        inv.g.name(to),
+				Direction: worldmap.Directions[inv.m.slots[i]],
+			})
+		}
+	}
+
+	inv.cross(visitedCities, moving)
+
+	return visitedCities
+}
+
+// noNeighbours are the neighbours of cities with no roads.
+var noNeighbours = [4]worldmap.CityID{worldmap.NoCity, worldmap.NoCity, worldmap.NoCity, worldmap.NoCity}
+
+// battlefields returns the cities in visited where more than one alien is, in alphabetical order.
+func battlefields(g graph, visited map[worldmap.CityID][]int) []worldmap.CityID {
+	cities := []worldmap.CityID{}
+	for c, aliens := range visited {
+		if len(aliens) > 1 {
+			cities = append(cities, c)
+		}
 	}
+	sort.Slice(cities, func(i, j int) bool {
+		return g.name(cities[i]) < g.name(cities[j])
+	})
 
-	fmt.Fprintln(out, "This is what the world looks like after the invasion:")
-	fmt.Fprintln(out, world)
+	return cities
 }
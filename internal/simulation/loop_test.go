@@ -3,11 +3,14 @@ package simulation
 import (
 	"bufio"
 	"bytes"
+	"fmt"
+	"math/rand"
 	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/generator"
 	"github.com/volmedo/invasim/internal/worldmap"
 )
 
@@ -30,29 +33,333 @@ func Test_Run(t *testing.T) {
 	}
 
 	alienTracker := aliens.Tracker{
-		"alien 0": "Foo",
-		"alien 1": "Bar",
-		"alien 2": "Baz",
-		"alien 3": "Qu-ux",
+		"alien 0": {City: "Foo"},
+		"alien 1": {City: "Bar"},
+		"alien 2": {City: "Baz"},
+		"alien 3": {City: "Qu-ux"},
 	}
 
 	maxIterations := 1
 	out := &bytes.Buffer{}
 
-	Run(world, alienTracker, maxIterations, out)
+	result := Run(world, alienTracker, maxIterations, rand.New(rand.NewSource(1)), NewTextObserver(out), Options{})
 
 	assert.NotContains(t, world, "Foo")
 	assert.NotContains(t, alienTracker, "alien 1")
 	assert.NotContains(t, alienTracker, "alien 2")
 	assert.NotContains(t, alienTracker, "alien 3")
 
+	assert.Equal(t, 1, result.Iterations)
+	assert.Equal(t, TerminationReason_MaxIterationsReached, result.Reason)
+	assert.Equal(t, aliens.Tracker{"alien 0": alienTracker["alien 0"]}, result.SurvivingAliens)
+	assert.Equal(t, world, result.World)
+	assert.Len(t, result.DestroyedCities, 1)
+	assert.Equal(t, "Foo", result.DestroyedCities[0].City)
+	assert.Equal(t, 1, result.DestroyedCities[0].Iteration)
+	assert.ElementsMatch(t, []string{"alien 1", "alien 2", "alien 3"}, result.DestroyedCities[0].Attackers)
+
 	scanner := bufio.NewScanner(out)
 	scanner.Scan()
 	assert.Regexp(t, regexp.MustCompile(`Foo has been destroyed by alien \d, alien \d and alien \d!`), scanner.Text())
-	scanner.Scan()
-	assert.Equal(t, "Simulation finished!", scanner.Text())
-	scanner.Scan()
-	assert.Equal(t, "Max iterations reached, 1 alien(s) remaining", scanner.Text())
-	scanner.Scan()
-	assert.Equal(t, "This is what the world looks like after the invasion:", scanner.Text())
+	assert.False(t, scanner.Scan())
+}
+
+func Test_Run_sameSeedSameOutput(t *testing.T) {
+	newWorld := func() worldmap.World {
+		return worldmap.World{
+			"Foo": worldmap.Roads{
+				worldmap.Direction_North: "Bar",
+				worldmap.Direction_West:  "Baz",
+				worldmap.Direction_South: "Qu-ux",
+			},
+			"Bar": worldmap.Roads{
+				worldmap.Direction_South: "Foo",
+				worldmap.Direction_West:  "Bee",
+			},
+			"Baz": worldmap.Roads{
+				worldmap.Direction_East: "Foo",
+			},
+			"Qu-ux": worldmap.Roads{
+				worldmap.Direction_North: "Foo",
+			},
+			"Bee": worldmap.Roads{
+				worldmap.Direction_East: "Bar",
+			},
+		}
+	}
+
+	run := func(seed int64) string {
+		world := newWorld()
+		rng := rand.New(rand.NewSource(seed))
+		alienTracker, err := aliens.NewTracker(3, world, nil, rng)
+		assert.Nil(t, err)
+
+		out := &bytes.Buffer{}
+		result := Run(world, alienTracker, 100, rng, NewJSONLObserver(out), Options{})
+		assert.Nil(t, WriteReport(out, result))
+
+		return out.String()
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		assert.Equal(t, run(seed), run(seed))
+	}
+}
+
+func Test_Run_events(t *testing.T) {
+	// Bar --- Foo --- Baz
+	world := worldmap.World{
+		"Foo": worldmap.Roads{
+			worldmap.Direction_West: "Bar",
+			worldmap.Direction_East: "Baz",
+		},
+		"Bar": worldmap.Roads{
+			worldmap.Direction_East: "Foo",
+		},
+		"Baz": worldmap.Roads{
+			worldmap.Direction_West: "Foo",
+		},
+	}
+
+	alienTracker := aliens.Tracker{
+		"alien 0": {City: "Bar"},
+		"alien 1": {City: "Baz"},
+	}
+
+	events := []Event{}
+	Run(world, alienTracker, 10, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		events = append(events, e)
+	}), Options{})
+
+	expected := []Event{
+		{Type: EventType_AlienPlaced, Alien: "alien 0", City: "Bar"},
+		{Type: EventType_AlienPlaced, Alien: "alien 1", City: "Baz"},
+		{Type: EventType_AlienMoved, Iteration: 1, Alien: "alien 0", From: "Bar", To: "Foo", Direction: worldmap.Direction_East},
+		{Type: EventType_AlienMoved, Iteration: 1, Alien: "alien 1", From: "Baz", To: "Foo", Direction: worldmap.Direction_West},
+		{Type: EventType_Battle, Iteration: 1, City: "Foo", Aliens: []string{"alien 0", "alien 1"}},
+		{Type: EventType_RoadRemoved, Iteration: 1, From: "Foo", To: "Baz", Direction: worldmap.Direction_East},
+		{Type: EventType_RoadRemoved, Iteration: 1, From: "Foo", To: "Bar", Direction: worldmap.Direction_West},
+		{Type: EventType_CityDestroyed, Iteration: 1, City: "Foo", Aliens: []string{"alien 0", "alien 1"}},
+		{
+			Type:      EventType_SimulationEnded,
+			Iteration: 1,
+			Aliens:    []string{},
+			Reason:    TerminationReason_AllAliensDestroyed,
+			World:     worldmap.World{"Bar": worldmap.Roads{}, "Baz": worldmap.Roads{}},
+		},
+	}
+	assert.Equal(t, expected, events)
+}
+
+func Test_Run_trappedAlien(t *testing.T) {
+	world := worldmap.World{
+		"Foo": worldmap.Roads{},
+	}
+
+	alienTracker := aliens.Tracker{
+		"alien 0": {City: "Foo"},
+	}
+
+	trappedEvents := 0
+	result := Run(world, alienTracker, 5, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		if e.Type == EventType_AlienTrapped {
+			trappedEvents++
+			assert.Equal(t, "alien 0", e.Alien)
+			assert.Equal(t, "Foo", e.City)
+		}
+	}), Options{})
+
+	// the alien is only reported as trapped once, and the simulation ends right away as it can't move
+	assert.Equal(t, 1, trappedEvents)
+	assert.Equal(t, 0, result.Iterations)
+	assert.Equal(t, TerminationReason_AllAliensTrapped, result.Reason)
+}
+
+// stringTerrain hides the type of the World it wraps, so that Run works with city names instead of converting it to
+// a worldmap.Compact.
+type stringTerrain struct {
+	worldmap.World
+}
+
+// recordRun runs a simulation of an invasion of world by numAliens aliens and returns the events it produces, leaving
+// the world out of them.
+func recordRun(t *testing.T, world worldmap.Terrain, numAliens int, opts Options) []Event {
+	rng := rand.New(rand.NewSource(2))
+	alienTracker, err := aliens.NewTracker(numAliens, world, nil, rng)
+	assert.Nil(t, err)
+
+	events := []Event{}
+	Run(world, alienTracker, 100, rng, ObserverFunc(func(e Event) {
+		e.World = nil
+		events = append(events, e)
+	}), opts)
+
+	return events
+}
+
+func Test_Run_sameResultOnAnyTerrain(t *testing.T) {
+	base, _, err := generator.Generate(generator.Config{Kind: generator.Kind_Sparse, Width: 20, Height: 20, Density: 0.3}, rand.New(rand.NewSource(1)))
+	assert.Nil(t, err)
+
+	expected := recordRun(t, base.Copy(), 60, Options{})
+	assert.Equal(t, expected, recordRun(t, worldmap.NewCompact(base), 60, Options{}))
+	assert.Equal(t, expected, recordRun(t, stringTerrain{base.Copy()}, 60, Options{}))
+}
+
+func Test_Run_parallelism(t *testing.T) {
+	base, _, err := generator.Generate(generator.Config{Kind: generator.Kind_Grid, Width: 80, Height: 80}, rand.New(rand.NewSource(1)))
+	assert.Nil(t, err)
+
+	// enough aliens to fill several shards
+	numAliens := 3*shardSize + 100
+	expected := recordRun(t, base.Copy(), numAliens, Options{})
+	for _, parallelism := range []int{1, 2, 3, 8} {
+		assert.Equal(t, expected, recordRun(t, base.Copy(), numAliens, Options{Parallelism: parallelism}))
+	}
+	assert.Equal(t, expected, recordRun(t, stringTerrain{base.Copy()}, numAliens, Options{Parallelism: 4}))
+}
+
+func Test_Run_strategies(t *testing.T) {
+	base, _, err := generator.Generate(generator.Config{Kind: generator.Kind_Sparse, Width: 20, Height: 20, Density: 0.3}, rand.New(rand.NewSource(1)))
+	assert.Nil(t, err)
+
+	// uniform aliens move exactly as when no strategy is given
+	expected := recordRun(t, base.Copy(), 60, Options{})
+	assert.Equal(t, expected, recordRun(t, base.Copy(), 60, Options{Strategy: aliens.Uniform{}}))
+
+	// a lazy alien staying in its city still fights the aliens that come to it
+	//
+	// Bar --- Foo
+	world := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_West: "Bar"},
+		"Bar": worldmap.Roads{worldmap.Direction_East: "Foo"},
+	}
+	alienTracker := aliens.Tracker{"alien 0": {City: "Bar"}, "alien 1": {City: "Foo"}}
+	opts := Options{Strategies: map[string]aliens.Strategy{"alien 1": aliens.Lazy{StayProbability: 1}}}
+
+	result := Run(world, alienTracker, 10, rand.New(rand.NewSource(1)), nil, opts)
+
+	assert.Equal(t, TerminationReason_AllAliensDestroyed, result.Reason)
+	assert.Equal(t, []DestroyedCity{{City: "Foo", Iteration: 1, Attackers: []string{"alien 0", "alien 1"}}}, result.DestroyedCities)
+}
+
+func Test_Run_speed(t *testing.T) {
+	// A --- B --- C --- D
+	world := worldmap.World{
+		"A": worldmap.Roads{worldmap.Direction_East: "B"},
+		"B": worldmap.Roads{worldmap.Direction_West: "A", worldmap.Direction_East: "C"},
+		"C": worldmap.Roads{worldmap.Direction_West: "B", worldmap.Direction_East: "D"},
+		"D": worldmap.Roads{worldmap.Direction_West: "C"},
+	}
+	// aliens are tough enough to survive a battle, should they meet
+	alienTracker := aliens.Tracker{
+		"alien 0": {City: "A", Health: 10, Speed: 2},
+		"alien 1": {City: "D", Health: 10},
+	}
+
+	moves := map[string]int{}
+	result := Run(world, alienTracker, 1, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		if e.Type == EventType_AlienMoved {
+			assert.Equal(t, 1, e.Iteration)
+			moves[e.Alien]++
+		}
+	}), Options{})
+
+	assert.Equal(t, map[string]int{"alien 0": 2, "alien 1": 1}, moves)
+	assert.Equal(t, 2, result.SurvivingAliens["alien 0"].Moves)
+	assert.Equal(t, 1, result.SurvivingAliens["alien 1"].Moves)
+}
+
+func Test_Run_speedStrategies(t *testing.T) {
+	// A --- B --- C --- D --- E
+	world := worldmap.World{
+		"A": worldmap.Roads{worldmap.Direction_East: "B"},
+		"B": worldmap.Roads{worldmap.Direction_West: "A", worldmap.Direction_East: "C"},
+		"C": worldmap.Roads{worldmap.Direction_West: "B", worldmap.Direction_East: "D"},
+		"D": worldmap.Roads{worldmap.Direction_West: "C", worldmap.Direction_East: "E"},
+		"E": worldmap.Roads{worldmap.Direction_West: "D"},
+	}
+	opts := Options{
+		Strategy:   aliens.Hunter{SightRange: 10},
+		Strategies: map[string]aliens.Strategy{"prey": aliens.Lazy{StayProbability: 1}},
+	}
+
+	// the hunter still sees the prey in its second step, even though the prey is too slow to take it
+	for seed := int64(0); seed < 20; seed++ {
+		alienTracker := aliens.Tracker{
+			"hunter": {City: "A", Health: 10, Speed: 2},
+			"prey":   {City: "E", Health: 10},
+		}
+
+		result := Run(world.Copy(), alienTracker, 1, rand.New(rand.NewSource(seed)), nil, opts)
+
+		assert.Equal(t, "C", result.SurvivingAliens["hunter"].City)
+	}
+}
+
+// Benchmark_Run benchmarks whole simulations. Only picking the roads aliens take is spread across goroutines, and the
+// rest of every iteration (collecting where aliens end up, fights, trapped aliens) is sequential, so "parallel 4" can
+// only be faster than "compact" on machines with several CPUs, and by less than Benchmark_move shows.
+func Benchmark_Run(b *testing.B) {
+	base, _, err := generator.Generate(generator.Config{Kind: generator.Kind_Sparse, Width: 600, Height: 600, Density: 0.2}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	compact := worldmap.NewCompact(base)
+
+	benchmarks := map[string]struct {
+		newTerrain func() worldmap.Terrain
+		opts       Options
+	}{
+		"names":      {func() worldmap.Terrain { return stringTerrain{base.Copy()} }, Options{}},
+		"compact":    {func() worldmap.Terrain { return compact.Clone() }, Options{}},
+		"parallel 1": {func() worldmap.Terrain { return compact.Clone() }, Options{Parallelism: 1}},
+		"parallel 4": {func() worldmap.Terrain { return compact.Clone() }, Options{Parallelism: 4}},
+	}
+
+	for name, bm := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				world := bm.newTerrain()
+				rng := rand.New(rand.NewSource(int64(i)))
+				alienTracker, err := aliens.NewTracker(100_000, world, nil, rng)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+
+				Run(world, alienTracker, 20, rng, nil, bm.opts)
+			}
+		})
+	}
+}
+
+// Benchmark_move benchmarks moving aliens a single step, which is the part of simulations that is spread across
+// goroutines. With a single CPU every case takes about the same time, as goroutines can't run at the same time.
+func Benchmark_move(b *testing.B) {
+	base, _, err := generator.Generate(generator.Config{Kind: generator.Kind_Sparse, Width: 600, Height: 600, Density: 0.2}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	g := compactGraph{worldmap.NewCompact(base)}
+
+	rng := rand.New(rand.NewSource(1))
+	names := make([]string, 100_000)
+	positions := make([]worldmap.CityID, len(names))
+	alive := make([]bool, len(names))
+	for i := range names {
+		names[i] = fmt.Sprintf("alien-%06d", i)
+		positions[i] = worldmap.CityID(rng.Intn(g.Len()))
+		alive[i] = true
+	}
+
+	for _, parallelism := range []int{0, 1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("parallel %d", parallelism), func(b *testing.B) {
+			m := newMover(g, names, Options{Parallelism: parallelism})
+			for i := 0; i < b.N; i++ {
+				m.move(names, positions, alive, alive, rng)
+			}
+		})
+	}
 }
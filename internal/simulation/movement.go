@@ -0,0 +1,245 @@
+package simulation
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/splitmix"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// shardSize is the number of consecutive aliens moved with the same random stream. It must never change with the
+// number of goroutines, or the outcome of simulations would depend on it.
+const shardSize = 1024
+
+// mover decides where aliens move to in every iteration.
+type mover struct {
+	g           graph
+	parallelism int
+	// strategies holds the strategy of every alien, or is nil if all of them move uniformly at random
+	strategies []aliens.Strategy
+	// slots holds, for every alien, the slot of the neighbours of its city it took in the last iteration, or -1 if it
+	// didn't move
+	slots []int
+	// dests holds, for every alien that moved in the last iteration, the city it moved to
+	dests []worldmap.CityID
+}
+
+// newMover creates a new mover for the aliens in names, moving through g as described in opts.
+func newMover(g graph, names []string, opts Options) *mover {
+	m := &mover{
+		g:           g,
+		parallelism: opts.Parallelism,
+		slots:       make([]int, len(names)),
+		dests:       make([]worldmap.CityID, len(names)),
+	}
+
+	if opts.Strategy != nil || len(opts.Strategies) > 0 {
+		m.strategies = make([]aliens.Strategy, len(names))
+		for i, a := range names {
+			switch {
+			case opts.Strategies[a] != nil:
+				m.strategies[i] = opts.Strategies[a]
+			case opts.Strategy != nil:
+				m.strategies[i] = opts.Strategy
+			default:
+				m.strategies[i] = aliens.Uniform{}
+			}
+		}
+	}
+
+	return m
+}
+
+// move decides where every alien in moving, given their names, moves to from its position and returns the aliens that
+// end up in every city, in the order they were moved. Aliens that don't move are in the city they already were at.
+// Strategies see every alien in alive, moving or not. positions is not updated: that is left to the caller, using
+// m.slots and m.dests.
+// Aliens are split into shards of consecutive aliens, and every shard is moved with its own random stream derived from
+// rng, so the outcome is the same whether shards are moved one after another or spread across several goroutines.
+// Only picking roads is spread across goroutines: shards write to disjoint parts of m.slots and m.dests, and the cities
+// aliens end up at are collected afterwards in a single pass.
+func (m *mover) move(
+	names []string,
+	positions []worldmap.CityID,
+	moving, alive []bool,
+	rng *rand.Rand,
+) map[worldmap.CityID][]int {
+	seed := rng.Int63()
+	if m.strategies != nil {
+		// strategies may keep track of aliens or look at them, so they are always run one alien after another
+		return m.moveWithStrategies(names, positions, moving, alive, seed)
+	}
+
+	numShards := (len(positions) + shardSize - 1) / shardSize
+	shardEnd := func(s int) int {
+		if end := (s + 1) * shardSize; end < len(positions) {
+			return end
+		}
+		return len(positions)
+	}
+
+	workers := m.parallelism
+	if _, concurrent := m.g.(compactGraph); !concurrent {
+		// other graphs keep track of the cities they find, so they can't be used from several goroutines
+		workers = 1
+	}
+
+	if workers <= 1 || numShards <= 1 {
+		for s := 0; s < numShards; s++ {
+			m.moveRange(positions, moving, s*shardSize, shardEnd(s), shardRand(seed, s))
+		}
+	} else {
+		wg := sync.WaitGroup{}
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for s := w; s < numShards; s += workers {
+					m.moveRange(positions, moving, s*shardSize, shardEnd(s), shardRand(seed, s))
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+
+	return m.visited(positions, moving)
+}
+
+// shardRand returns the random stream the aliens in shard s are moved with, given the seed of the current step.
+func shardRand(seed int64, s int) *rand.Rand {
+	src := splitmix.Source(splitmix.Mix(uint64(seed) ^ splitmix.Mix(uint64(s))))
+	return rand.New(&src)
+}
+
+// moveRange decides where the alive aliens with indexes from start (inclusive) to end (exclusive) move to, using rng,
+// and records it in m.slots and m.dests.
+func (m *mover) moveRange(positions []worldmap.CityID, alive []bool, start, end int, rng *rand.Rand) {
+	for i := start; i < end; i++ {
+		if !alive[i] {
+			continue
+		}
+
+		neighbours := m.g.neighbours(positions[i])
+		slot := pickRandomSlot(neighbours, rng)
+		m.slots[i] = slot
+		if slot >= 0 {
+			m.dests[i] = neighbours[slot]
+		}
+	}
+}
+
+// visited returns the aliens that end up in every city after the alive aliens moved as recorded in m.slots and
+// m.dests, in alphabetical order.
+func (m *mover) visited(positions []worldmap.CityID, alive []bool) map[worldmap.CityID][]int {
+	visited := make(map[worldmap.CityID][]int, len(positions))
+	for i := range positions {
+		if !alive[i] {
+			continue
+		}
+
+		city := positions[i]
+		if m.slots[i] >= 0 {
+			city = m.dests[i]
+		}
+		if city != worldmap.NoCity {
+			visited[city] = append(visited[city], i)
+		}
+	}
+
+	return visited
+}
+
+// moveWithStrategies is like move, but lets the strategy of every alien in moving decide where it moves to, seeing
+// every alien in alive. Aliens are moved one after another, drawing from the random stream of their shard given seed.
+func (m *mover) moveWithStrategies(
+	names []string,
+	positions []worldmap.CityID,
+	moving, alive []bool,
+	seed int64,
+) map[worldmap.CityID][]int {
+	roads := graphRoads{m.g}
+	others := aliens.Occupancy{}
+	for i, a := range names {
+		if alive[i] && positions[i] != worldmap.NoCity {
+			city := m.g.name(positions[i])
+			others[city] = append(others[city], a)
+		}
+	}
+
+	var rng *rand.Rand
+	for i, a := range names {
+		if i%shardSize == 0 {
+			rng = shardRand(seed, i/shardSize)
+		}
+		if !moving[i] {
+			continue
+		}
+
+		m.slots[i] = -1
+		if positions[i] == worldmap.NoCity {
+			continue
+		}
+
+		neighbours := m.g.neighbours(positions[i])
+		dir := m.strategies[i].Move(a, m.g.name(positions[i]), roads, others, rng)
+		for slot, d := range worldmap.Directions {
+			if d == dir && neighbours[slot] != worldmap.NoCity {
+				m.slots[i] = slot
+			}
+		}
+
+		if m.slots[i] >= 0 {
+			m.dests[i] = neighbours[m.slots[i]]
+		}
+	}
+
+	return m.visited(positions, moving)
+}
+
+// graphRoads is the aliens.RoadMap strategies see a graph through.
+type graphRoads struct {
+	g graph
+}
+
+// Roads implements the aliens.RoadMap interface.
+func (r graphRoads) Roads(city string) worldmap.Roads {
+	roads := worldmap.Roads{}
+	for slot, dest := range r.g.neighbours(r.g.id(city)) {
+		if dest != worldmap.NoCity {
+			roads[worldmap.Directions[slot]] = r.g.name(dest)
+		}
+	}
+
+	return roads
+}
+
+// pickRandomSlot picks one of the slots of neighbours that hold a city at random, or returns -1 if there is none. It
+// takes the same decisions as aliens.Uniform for the same state of rng.
+func pickRandomSlot(neighbours [4]worldmap.CityID, rng *rand.Rand) int {
+	available := 0
+	for _, dest := range neighbours {
+		if dest != worldmap.NoCity {
+			available++
+		}
+	}
+
+	if available == 0 {
+		return -1
+	}
+
+	randIdx := rng.Intn(available)
+	for slot, dest := range neighbours {
+		if dest == worldmap.NoCity {
+			continue
+		}
+
+		if randIdx == 0 {
+			return slot
+		}
+		randIdx--
+	}
+
+	return -1
+}
@@ -0,0 +1,98 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TextObserver is an Observer that writes human-readable messages about the outcome of battles, and about fights on
+// roads, to an io.Writer. The rest of the events are ignored.
+type TextObserver struct {
+	out io.Writer
+}
+
+// NewTextObserver creates a new TextObserver that writes its messages to out.
+func NewTextObserver(out io.Writer) *TextObserver {
+	return &TextObserver{out: out}
+}
+
+// Notify implements the Observer interface.
+func (o *TextObserver) Notify(event Event) {
+	switch event.Type {
+	case EventType_CityDestroyed:
+		fmt.Fprintf(
+			o.out,
+			"%s has been destroyed by %s and %s!\n",
+			event.City, strings.Join(event.Aliens[:len(event.Aliens)-1], ", "), event.Aliens[len(event.Aliens)-1],
+		)
+
+	case EventType_CityDefended:
+		fmt.Fprintf(
+			o.out,
+			"%s has withstood the attack of %s and %s!\n",
+			event.City, strings.Join(event.Aliens[:len(event.Aliens)-1], ", "), event.Aliens[len(event.Aliens)-1],
+		)
+
+	case EventType_AlienSurvived:
+		if event.From != "" {
+			fmt.Fprintf(
+				o.out,
+				"%s has survived the fight on the road between %s and %s with %d health point(s) left!\n",
+				event.Alien, event.From, event.To, event.Health,
+			)
+			break
+		}
+
+		fmt.Fprintf(o.out, "%s has survived the battle in %s with %d health point(s) left!\n", event.Alien, event.City, event.Health)
+
+	case EventType_RoadDestroyed:
+		fmt.Fprintf(
+			o.out,
+			"The road between %s and %s has been destroyed by %s and %s!\n",
+			event.From, event.To, event.Aliens[0], event.Aliens[1],
+		)
+
+	case EventType_AliensDestroyed:
+		if len(event.Aliens) == 1 {
+			fmt.Fprintf(
+				o.out,
+				"%s has been destroyed on the road between %s and %s!\n",
+				event.Aliens[0], event.From, event.To,
+			)
+			break
+		}
+
+		fmt.Fprintf(
+			o.out,
+			"%s and %s have destroyed each other on the road between %s and %s!\n",
+			event.Aliens[0], event.Aliens[1], event.From, event.To,
+		)
+	}
+}
+
+// JSONLObserver is an Observer that encodes every event as a JSON object in its own line (JSON Lines format).
+type JSONLObserver struct {
+	encoder *json.Encoder
+	err     error
+}
+
+// NewJSONLObserver creates a new JSONLObserver that writes encoded events to out.
+func NewJSONLObserver(out io.Writer) *JSONLObserver {
+	return &JSONLObserver{encoder: json.NewEncoder(out)}
+}
+
+// Notify implements the Observer interface. Once an event fails to be encoded, the rest of them are discarded.
+func (o *JSONLObserver) Notify(event Event) {
+	if o.err != nil {
+		return
+	}
+
+	o.err = o.encoder.Encode(event)
+}
+
+// Err returns the first error found while encoding events, if any.
+func (o *JSONLObserver) Err() error {
+	return o.err
+}
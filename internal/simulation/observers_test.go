@@ -0,0 +1,110 @@
+package simulation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_TextObserver(t *testing.T) {
+	testCases := map[string]struct {
+		events         []Event
+		expectedOutput string
+	}{
+		"city destroyed": {
+			events: []Event{
+				{Type: EventType_AlienMoved, Iteration: 1, Alien: "alien 0", From: "Foo", To: "Bar"},
+				{Type: EventType_CityDestroyed, Iteration: 1, City: "Bar", Aliens: []string{"alien 0", "alien 1", "alien 2"}},
+			},
+			expectedOutput: "Bar has been destroyed by alien 0, alien 1 and alien 2!\n",
+		},
+		"city defended": {
+			events: []Event{
+				{Type: EventType_CityDefended, Iteration: 1, City: "Bar", Aliens: []string{"alien 0", "alien 1"}},
+				{Type: EventType_AlienSurvived, Iteration: 1, Alien: "alien 1", City: "Bar", Health: 2},
+			},
+			expectedOutput: "Bar has withstood the attack of alien 0 and alien 1!\nalien 1 has survived the battle in Bar with 2 health point(s) left!\n",
+		},
+		"road destroyed": {
+			events: []Event{
+				{Type: EventType_RoadDestroyed, Iteration: 1, From: "Foo", To: "Bar", Aliens: []string{"alien 0", "alien 1"}},
+			},
+			expectedOutput: "The road between Foo and Bar has been destroyed by alien 0 and alien 1!\n",
+		},
+		"aliens destroyed": {
+			events: []Event{
+				{Type: EventType_AliensDestroyed, Iteration: 1, From: "Foo", To: "Bar", Aliens: []string{"alien 0", "alien 1"}},
+			},
+			expectedOutput: "alien 0 and alien 1 have destroyed each other on the road between Foo and Bar!\n",
+		},
+		"alien destroyed on a road": {
+			events: []Event{
+				{Type: EventType_AliensDestroyed, Iteration: 1, From: "Foo", To: "Bar", Aliens: []string{"alien 1"}},
+				{Type: EventType_AlienSurvived, Iteration: 1, Alien: "alien 0", City: "Bar", From: "Foo", To: "Bar", Health: 2},
+			},
+			expectedOutput: "alien 1 has been destroyed on the road between Foo and Bar!\n" +
+				"alien 0 has survived the fight on the road between Foo and Bar with 2 health point(s) left!\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			observer := NewTextObserver(out)
+			for _, e := range tc.events {
+				observer.Notify(e)
+			}
+
+			assert.Equal(t, tc.expectedOutput, out.String())
+		})
+	}
+}
+
+func Test_JSONLObserver(t *testing.T) {
+	out := &bytes.Buffer{}
+	observer := NewJSONLObserver(out)
+
+	observer.Notify(Event{Type: EventType_AlienPlaced, Alien: "alien 0", City: "Foo"})
+	observer.Notify(Event{
+		Type:      EventType_AlienMoved,
+		Iteration: 1,
+		Alien:     "alien 0",
+		From:      "Foo",
+		To:        "Bar",
+		Direction: worldmap.Direction_North,
+	})
+	observer.Notify(Event{
+		Type:      EventType_SimulationEnded,
+		Iteration: 1,
+		Reason:    TerminationReason_MaxIterationsReached,
+		Aliens:    []string{"alien 0"},
+		World: worldmap.World{
+			"Foo": worldmap.Roads{worldmap.Direction_North: "Bar"},
+			"Bar": worldmap.Roads{worldmap.Direction_South: "Foo"},
+		},
+	})
+
+	assert.Nil(t, observer.Err())
+
+	expected := `{"type":"alien_placed","iteration":0,"alien":"alien 0","city":"Foo"}
+{"type":"alien_moved","iteration":1,"alien":"alien 0","from":"Foo","to":"Bar","direction":"north"}
+{"type":"simulation_ended","iteration":1,"aliens":["alien 0"],"reason":"max_iterations_reached","world":{"Bar":{"south":"Foo"},"Foo":{"north":"Bar"}}}
+`
+	assert.Equal(t, expected, out.String())
+}
+
+func Test_Observers(t *testing.T) {
+	received := [][]EventType{{}, {}}
+	observers := Observers{
+		ObserverFunc(func(e Event) { received[0] = append(received[0], e.Type) }),
+		ObserverFunc(func(e Event) { received[1] = append(received[1], e.Type) }),
+	}
+
+	observers.Notify(Event{Type: EventType_AlienPlaced})
+	observers.Notify(Event{Type: EventType_SimulationEnded})
+
+	expected := []EventType{EventType_AlienPlaced, EventType_SimulationEnded}
+	assert.Equal(t, [][]EventType{expected, expected}, received)
+}
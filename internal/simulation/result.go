@@ -0,0 +1,90 @@
+package simulation
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// Result summarizes the outcome of a simulation.
+type Result struct {
+	// Iterations is the number of iterations that were actually executed.
+	Iterations int
+	// Reason explains why the simulation stopped.
+	Reason TerminationReason
+	// DestroyedCities lists the cities destroyed during the invasion, in the order they fell.
+	DestroyedCities []DestroyedCity
+	// Battles lists the battles fought during the invasion and their outcomes, in the order they were fought.
+	Battles []Battle
+	// SurvivingAliens tracks the aliens still alive at the end of the simulation and the cities they are at.
+	SurvivingAliens aliens.Tracker
+	// StrandedAliens tracks the aliens removed from the simulation after being trapped and the cities they were
+	// trapped in.
+	StrandedAliens aliens.Tracker
+	// RoadCrossings lists the times aliens crossed each other on a road and fought there, in the order they happened.
+	RoadCrossings []RoadCrossing
+	// World is what the world looks like after the invasion, or nil if the invasion didn't take place in a
+	// worldmap.World.
+	World worldmap.World
+}
+
+// DestroyedCity records the destruction of a city.
+type DestroyedCity struct {
+	City      string
+	Iteration int
+	Attackers []string
+}
+
+// WriteReport writes a human-readable summary of result to out. The world is only written if result has one, otherwise
+// the number of destroyed cities is reported instead.
+func WriteReport(out io.Writer, result Result) error {
+	report := "Simulation finished!\n"
+	switch result.Reason {
+	case TerminationReason_AllAliensDestroyed:
+		report += "All aliens were destroyed!\n"
+	case TerminationReason_MaxIterationsReached:
+		report += fmt.Sprintf("Max iterations reached, %d alien(s) remaining\n", len(result.SurvivingAliens))
+	case TerminationReason_AllAliensTrapped:
+		report += fmt.Sprintf("No alien can move anymore, %d alien(s) remaining\n", len(result.SurvivingAliens))
+	case TerminationReason_NoEncountersPossible:
+		report += fmt.Sprintf("No alien can meet another anymore, %d alien(s) remaining\n", len(result.SurvivingAliens))
+	}
+	if len(result.StrandedAliens) > 0 {
+		report += fmt.Sprintf("%d alien(s) stranded\n", len(result.StrandedAliens))
+	}
+	if survivors := countSurvivors(result.Battles); survivors > 0 {
+		report += fmt.Sprintf("%d alien(s) survived a battle\n", survivors)
+	}
+	if defended := len(result.Battles) - len(result.DestroyedCities); defended > 0 {
+		report += fmt.Sprintf("%d battle(s) left the city standing\n", defended)
+	}
+	if len(result.RoadCrossings) > 0 {
+		report += fmt.Sprintf("%d fight(s) on roads\n", len(result.RoadCrossings))
+	}
+
+	if result.World == nil {
+		report += fmt.Sprintf("%d cities were destroyed\n", len(result.DestroyedCities))
+		_, err := io.WriteString(out, report)
+
+		return err
+	}
+
+	report += "This is what the world looks like after the invasion:\n"
+	if _, err := io.WriteString(out, report); err != nil {
+		return err
+	}
+
+	return worldmap.Format(out, result.World, worldmap.FormatOptions{})
+}
+
+// countSurvivors returns the number of aliens that survived any of battles.
+func countSurvivors(battles []Battle) int {
+	survivors := 0
+	for _, b := range battles {
+		survivors += len(b.Outcome.Survivors)
+	}
+
+	return survivors
+}
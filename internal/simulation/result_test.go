@@ -0,0 +1,60 @@
+package simulation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+func Test_WriteReport(t *testing.T) {
+	testCases := map[string]struct {
+		result         Result
+		expectedReport string
+	}{
+		"all aliens destroyed": {
+			result: Result{
+				Iterations:      3,
+				Reason:          TerminationReason_AllAliensDestroyed,
+				SurvivingAliens: aliens.Tracker{},
+				World: worldmap.World{
+					"Foo": worldmap.Roads{worldmap.Direction_North: "Bar"},
+					"Bar": worldmap.Roads{worldmap.Direction_South: "Foo"},
+				},
+			},
+			expectedReport: "Simulation finished!\nAll aliens were destroyed!\n" +
+				"This is what the world looks like after the invasion:\nBar south=Foo\nFoo north=Bar\n",
+		},
+		"max iterations reached": {
+			result: Result{
+				Iterations:      10,
+				Reason:          TerminationReason_MaxIterationsReached,
+				SurvivingAliens: aliens.Tracker{"alien 0": {City: "Foo"}, "alien 1": {City: "Bar"}},
+				World:           worldmap.World{"Foo": worldmap.Roads{}},
+			},
+			expectedReport: "Simulation finished!\nMax iterations reached, 2 alien(s) remaining\n" +
+				"This is what the world looks like after the invasion:\nFoo\n",
+		},
+		"no world": {
+			result: Result{
+				Iterations:      5,
+				Reason:          TerminationReason_AllAliensDestroyed,
+				DestroyedCities: []DestroyedCity{{City: "E0N0", Iteration: 5, Attackers: []string{"alien 0", "alien 1"}}},
+				SurvivingAliens: aliens.Tracker{},
+			},
+			expectedReport: "Simulation finished!\nAll aliens were destroyed!\n1 cities were destroyed\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := WriteReport(out, tc.result)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedReport, out.String())
+		})
+	}
+}
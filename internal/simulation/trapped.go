@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// TrappedPolicy decides what happens to aliens that are trapped in a city with no roads left to take.
+type TrappedPolicy string
+
+const (
+	// TrappedPolicy_Keep keeps trapped aliens where they are for the rest of the simulation.
+	TrappedPolicy_Keep TrappedPolicy = "keep"
+	// TrappedPolicy_Strand removes trapped aliens from the simulation. They are reported as stranded.
+	TrappedPolicy_Strand TrappedPolicy = "strand"
+	// TrappedPolicy_DigOut lets trapped aliens dig their way out after Options.DigOutTurns iterations. They emerge in a
+	// random city that has not been destroyed at the start of an iteration, and move on from there in that same
+	// iteration like any other alien.
+	TrappedPolicy_DigOut TrappedPolicy = "dig-out"
+)
+
+// TrappedPolicies lists every policy for trapped aliens.
+var TrappedPolicies = []TrappedPolicy{TrappedPolicy_Keep, TrappedPolicy_Strand, TrappedPolicy_DigOut}
+
+// ParseTrappedPolicy returns the policy for trapped aliens with the given name.
+func ParseTrappedPolicy(name string) (TrappedPolicy, error) {
+	for _, policy := range TrappedPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown policy %q, it must be one of %q", name, TrappedPolicies)
+}
+
+// checkTrapped finds the aliens that have just become trapped, reports them and applies the trapped policy to them.
+func (inv *invasion) checkTrapped() {
+	for i, a := range inv.names {
+		if !inv.alive[i] || inv.trappedFor[i] >= 0 || inv.g.neighbours(inv.positions[i]) != noNeighbours {
+			continue
+		}
+
+		inv.trappedFor[i] = 0
+		city := inv.cityName(i)
+		inv.notify(Event{Type: EventType_AlienTrapped, Iteration: inv.iteration, Alien: a, City: city})
+
+		if inv.opts.TrappedPolicy == TrappedPolicy_Strand {
+			inv.alive[i] = false
+			inv.numAlive--
+			stranded := inv.attrs[i]
+			stranded.City = city
+			inv.strandedAliens[a] = stranded
+			inv.notify(Event{Type: EventType_AlienStranded, Iteration: inv.iteration, Alien: a, City: city})
+		}
+	}
+}
+
+// canMove reports whether any alien can still move, now or in the future.
+func (inv *invasion) canMove() bool {
+	if inv.opts.TrappedPolicy == TrappedPolicy_DigOut {
+		return true
+	}
+
+	for i := range inv.names {
+		if inv.alive[i] && inv.trappedFor[i] < 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// digOut lets the aliens that have been trapped for long enough dig their way out, if the trapped policy allows it.
+func (inv *invasion) digOut() {
+	if inv.opts.TrappedPolicy != TrappedPolicy_DigOut {
+		return
+	}
+
+	for i, a := range inv.names {
+		if !inv.alive[i] || inv.trappedFor[i] < 0 {
+			continue
+		}
+
+		inv.trappedFor[i]++
+		if inv.trappedFor[i] <= inv.opts.DigOutTurns {
+			continue
+		}
+
+		to := inv.g.randomCity(inv.rng)
+		if to == worldmap.NoCity {
+			continue
+		}
+
+		from := inv.cityName(i)
+		inv.positions[i] = to
+		inv.trappedFor[i] = -1
+		inv.notify(Event{Type: EventType_AlienDugOut, Iteration: inv.iteration, Alien: a, From: from, To: inv.g.name(to)})
+	}
+}
@@ -0,0 +1,115 @@
+package simulation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volmedo/invasim/internal/aliens"
+	"github.com/volmedo/invasim/internal/worldmap"
+)
+
+// trappedTestWorld returns a world where aliens 0 and 1 meet in Foo in the first iteration, while alien 2 is trapped in
+// Xen from the start:
+//
+//	Bar --- Foo --- Baz     Xen
+func trappedTestWorld() (worldmap.World, aliens.Tracker) {
+	world := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_West: "Bar", worldmap.Direction_East: "Baz"},
+		"Bar": worldmap.Roads{worldmap.Direction_East: "Foo"},
+		"Baz": worldmap.Roads{worldmap.Direction_West: "Foo"},
+		"Xen": worldmap.Roads{},
+	}
+	alienTracker := aliens.Tracker{"alien 0": {City: "Bar"}, "alien 1": {City: "Baz"}, "alien 2": {City: "Xen"}}
+
+	return world, alienTracker
+}
+
+func Test_Run_trappedPolicy_keep(t *testing.T) {
+	world, alienTracker := trappedTestWorld()
+
+	events := []Event{}
+	result := Run(world, alienTracker, 100, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		events = append(events, e)
+	}), Options{TrappedPolicy: TrappedPolicy_Keep})
+
+	assert.Equal(t, Event{Type: EventType_AlienTrapped, Alien: "alien 2", City: "Xen"}, events[3])
+	assert.Equal(t, TerminationReason_AllAliensTrapped, result.Reason)
+	assert.Equal(t, 1, result.Iterations)
+	assert.Equal(t, map[string]string{"alien 2": "Xen"}, result.SurvivingAliens.Cities())
+	assert.Empty(t, result.StrandedAliens)
+}
+
+func Test_Run_trappedPolicy_strand(t *testing.T) {
+	world, alienTracker := trappedTestWorld()
+
+	events := []Event{}
+	result := Run(world, alienTracker, 100, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		events = append(events, e)
+	}), Options{TrappedPolicy: TrappedPolicy_Strand})
+
+	assert.Equal(t, Event{Type: EventType_AlienTrapped, Alien: "alien 2", City: "Xen"}, events[3])
+	assert.Equal(t, Event{Type: EventType_AlienStranded, Alien: "alien 2", City: "Xen"}, events[4])
+	assert.Equal(t, TerminationReason_AllAliensTrapped, result.Reason)
+	assert.Equal(t, 1, result.Iterations)
+	assert.Empty(t, result.SurvivingAliens)
+	assert.Equal(t, map[string]string{"alien 2": "Xen"}, result.StrandedAliens.Cities())
+	assert.Len(t, result.DestroyedCities, 1)
+}
+
+func Test_Run_trappedPolicy_digOut(t *testing.T) {
+	// Bar --- Foo     Xen
+	world := worldmap.World{
+		"Foo": worldmap.Roads{worldmap.Direction_West: "Bar"},
+		"Bar": worldmap.Roads{worldmap.Direction_East: "Foo"},
+		"Xen": worldmap.Roads{},
+	}
+	alienTracker := aliens.Tracker{"alien 0": {City: "Xen"}, "alien 1": {City: "Bar"}}
+
+	dugOut := []Event{}
+	result := Run(world, alienTracker, 20, rand.New(rand.NewSource(1)), ObserverFunc(func(e Event) {
+		if e.Type == EventType_AlienDugOut {
+			dugOut = append(dugOut, e)
+		}
+	}), Options{TrappedPolicy: TrappedPolicy_DigOut, DigOutTurns: 2})
+
+	// alien 0 waits for 2 iterations and digs its way out in the third one
+	assert.GreaterOrEqual(t, result.Iterations, 3)
+	if assert.NotEmpty(t, dugOut) {
+		assert.Equal(t, 3, dugOut[0].Iteration)
+		assert.Equal(t, "alien 0", dugOut[0].Alien)
+		assert.Equal(t, "Xen", dugOut[0].From)
+		assert.Contains(t, []string{"Foo", "Bar", "Xen"}, dugOut[0].To)
+	}
+}
+
+func Test_ParseTrappedPolicy(t *testing.T) {
+	for _, policy := range TrappedPolicies {
+		got, err := ParseTrappedPolicy(string(policy))
+		assert.NoError(t, err)
+		assert.Equal(t, policy, got)
+	}
+
+	_, err := ParseTrappedPolicy("teleport")
+	assert.Error(t, err)
+}
+
+func Test_compactGraph_randomCity(t *testing.T) {
+	g := compactGraph{worldmap.NewCompact(worldmap.World{"Foo": {}, "Bar": {}, "Baz": {}})}
+	barID, _ := g.ID("Bar")
+	g.destroy(barID)
+
+	rng := rand.New(rand.NewSource(1))
+	picked := map[string]int{}
+	for i := 0; i < 100; i++ {
+		picked[g.name(g.randomCity(rng))]++
+	}
+	assert.Len(t, picked, 2)
+	assert.NotContains(t, picked, "Bar")
+
+	for _, city := range []string{"Foo", "Baz"} {
+		id, _ := g.ID(city)
+		g.destroy(id)
+	}
+	assert.Equal(t, worldmap.NoCity, g.randomCity(rng))
+}
@@ -0,0 +1,31 @@
+// Package splitmix implements the SplitMix64 generator, a fast source of pseudo-random numbers whose state is a single
+// integer, and the finalizer it uses to scramble bits, which makes a good hash function for integers too.
+package splitmix
+
+// Source is a rand.Source64 implementing the SplitMix64 generator. Unlike the sources created by rand.NewSource, it is
+// cheap enough to create a new one whenever an independent random stream is needed.
+type Source uint64
+
+// Seed implements the rand.Source interface.
+func (s *Source) Seed(seed int64) {
+	*s = Source(seed)
+}
+
+// Uint64 implements the rand.Source64 interface.
+func (s *Source) Uint64() uint64 {
+	*s += 0x9e3779b97f4a7c15
+	return Mix(uint64(*s) - 0x9e3779b97f4a7c15)
+}
+
+// Int63 implements the rand.Source interface.
+func (s *Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Mix scrambles the bits of x using the finalizer of the SplitMix64 generator.
+func Mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,28 @@
+package splitmix
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Source(t *testing.T) {
+	// first outputs of SplitMix64 seeded with 0, as given by its reference implementation
+	src := Source(0)
+	assert.Equal(t, uint64(0xe220a8397b1dcdaf), src.Uint64())
+	assert.Equal(t, uint64(0x6e789e6aa1b965f4), src.Uint64())
+
+	// sources seeded alike produce the same streams
+	src1, src2 := Source(1), Source(0)
+	src2.Seed(1)
+	rng1, rng2 := rand.New(&src1), rand.New(&src2)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, rng1.Int63(), rng2.Int63())
+	}
+}
+
+func Test_Mix(t *testing.T) {
+	assert.Equal(t, uint64(0xe220a8397b1dcdaf), Mix(0))
+	assert.NotEqual(t, Mix(1), Mix(2))
+}
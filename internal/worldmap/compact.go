@@ -0,0 +1,233 @@
+package worldmap
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// CityID identifies a city in a Compact world.
+type CityID int32
+
+// NoCity is the CityID used where there is no city, like in the slots of Neighbours with no road.
+const NoCity CityID = -1
+
+// Compact is a World where cities are identified by integers instead of their names, meant for simulations of worlds
+// with millions of cities. Cities get consecutive IDs, starting from 0, in alphabetical order, and the neighbours of
+// every city are kept in an array with a slot per direction instead of a map. Destroying a city doesn't change any of
+// that: destroyed cities are just marked in a bitset, which is checked whenever neighbours are looked up. Destroyed
+// roads are marked in a bitset too, with a bit per slot, which is only allocated once the first road is destroyed.
+//
+// Compact implements Terrain, but the methods that take and return city names are as slow as the ones of World. Use
+// ID, Name and Neighbours to get the speed up.
+type Compact struct {
+	names      []string
+	ids        map[string]CityID
+	neighbours [][4]CityID
+	destroyed  []uint64
+	// removed has a bit for every slot of neighbours, set if the road in that slot has been destroyed, or is nil if
+	// no road has
+	removed []uint64
+}
+
+// NewCompact creates a new Compact world with the same cities and roads as world.
+func NewCompact(world World) *Compact {
+	names := world.Cities()
+	c := &Compact{
+		names:      names,
+		ids:        make(map[string]CityID, len(names)),
+		neighbours: make([][4]CityID, len(names)),
+		destroyed:  make([]uint64, (len(names)+63)/64),
+	}
+
+	for i, name := range names {
+		c.ids[name] = CityID(i)
+	}
+
+	for i, name := range names {
+		for slot, dir := range Directions {
+			c.neighbours[i][slot] = NoCity
+			if dest, ok := world[name][dir]; ok {
+				if id, exists := c.ids[dest]; exists {
+					c.neighbours[i][slot] = id
+				}
+			}
+		}
+	}
+
+	return c
+}
+
+// Clone returns a copy of c that can be mutated without affecting c. Names and roads are shared, as they never change,
+// so cloning only takes as much memory as the bitsets of destroyed cities and roads.
+func (c *Compact) Clone() *Compact {
+	clone := *c
+	clone.destroyed = make([]uint64, len(c.destroyed))
+	copy(clone.destroyed, c.destroyed)
+	if c.removed != nil {
+		clone.removed = make([]uint64, len(c.removed))
+		copy(clone.removed, c.removed)
+	}
+
+	return &clone
+}
+
+// Len returns the number of cities in c, including destroyed ones. Valid IDs go from 0 to Len() - 1.
+func (c *Compact) Len() int {
+	return len(c.names)
+}
+
+// ID returns the ID of the city with the given name, or false if there is no such city. Destroyed cities keep their ID.
+func (c *Compact) ID(city string) (CityID, bool) {
+	id, ok := c.ids[city]
+	return id, ok
+}
+
+// Name returns the name of the city with the given ID.
+func (c *Compact) Name(id CityID) string {
+	return c.names[id]
+}
+
+// Neighbours returns the IDs of the cities that can be reached from the city with the given ID, with a slot per
+// direction in the order given by Directions. Slots with no road, with a destroyed road or with a road to a destroyed
+// city hold NoCity. Destroyed cities have no neighbours.
+func (c *Compact) Neighbours(id CityID) [4]CityID {
+	if c.IsDestroyed(id) {
+		return [4]CityID{NoCity, NoCity, NoCity, NoCity}
+	}
+
+	neighbours := c.neighbours[id]
+	for slot, dest := range neighbours {
+		if dest != NoCity && (c.IsDestroyed(dest) || c.isRemoved(id, slot)) {
+			neighbours[slot] = NoCity
+		}
+	}
+
+	return neighbours
+}
+
+// RemoveRoad destroys the road in the given slot of the neighbours of the city with the given ID, along with the road
+// back from its destination.
+func (c *Compact) RemoveRoad(id CityID, slot int) {
+	dest := c.neighbours[id][slot]
+	if dest == NoCity {
+		return
+	}
+
+	if c.removed == nil {
+		c.removed = make([]uint64, (4*len(c.names)+63)/64)
+	}
+
+	c.markRemoved(id, slot)
+	for backSlot, origin := range c.neighbours[dest] {
+		if origin == id {
+			c.markRemoved(dest, backSlot)
+		}
+	}
+}
+
+// markRemoved sets the bit of the given slot of the neighbours of the city with the given ID in the removed bitset.
+func (c *Compact) markRemoved(id CityID, slot int) {
+	bit := 4*int(id) + slot
+	c.removed[bit/64] |= 1 << (bit % 64)
+}
+
+// isRemoved reports whether the road in the given slot of the neighbours of the city with the given ID has been
+// destroyed.
+func (c *Compact) isRemoved(id CityID, slot int) bool {
+	if c.removed == nil {
+		return false
+	}
+
+	bit := 4*int(id) + slot
+	return c.removed[bit/64]&(1<<(bit%64)) != 0
+}
+
+// Destroy marks the city with the given ID as destroyed, which also removes the roads leading to and from it.
+func (c *Compact) Destroy(id CityID) {
+	c.destroyed[id/64] |= 1 << (id % 64)
+}
+
+// IsDestroyed reports whether the city with the given ID has been destroyed.
+func (c *Compact) IsDestroyed(id CityID) bool {
+	return c.destroyed[id/64]&(1<<(id%64)) != 0
+}
+
+// World converts c back to a World, leaving destroyed cities out.
+func (c *Compact) World() World {
+	world := make(World, len(c.names))
+	for i, name := range c.names {
+		id := CityID(i)
+		if c.IsDestroyed(id) {
+			continue
+		}
+
+		roads := Roads{}
+		for slot, dest := range c.Neighbours(id) {
+			if dest != NoCity {
+				roads[Directions[slot]] = c.names[dest]
+			}
+		}
+		world[name] = roads
+	}
+
+	return world
+}
+
+// Roads implements the Terrain interface.
+func (c *Compact) Roads(city string) Roads {
+	id, ok := c.ids[city]
+	if !ok {
+		return Roads{}
+	}
+
+	roads := Roads{}
+	for slot, dest := range c.Neighbours(id) {
+		if dest != NoCity {
+			roads[Directions[slot]] = c.names[dest]
+		}
+	}
+
+	return roads
+}
+
+// DestroyCity implements the Terrain interface.
+func (c *Compact) DestroyCity(city string) {
+	if id, ok := c.ids[city]; ok {
+		c.Destroy(id)
+	}
+}
+
+// DestroyRoad implements the Terrain interface.
+func (c *Compact) DestroyRoad(city string, dir Direction) {
+	id, ok := c.ids[city]
+	if !ok {
+		return
+	}
+
+	for slot, d := range Directions {
+		if d == dir {
+			c.RemoveRoad(id, slot)
+		}
+	}
+}
+
+// RandomCities implements the Terrain interface. Cities are chosen exactly like World.RandomCities would choose them
+// from c.World(), so converting a World doesn't change where aliens are placed.
+func (c *Compact) RandomCities(n int, rng *rand.Rand) ([]string, error) {
+	cities := make([]string, 0, len(c.names))
+	for i, name := range c.names {
+		if !c.IsDestroyed(CityID(i)) {
+			cities = append(cities, name)
+		}
+	}
+
+	if n > len(cities) {
+		return nil, fmt.Errorf("not enough cities (%d) to place %d aliens", len(cities), n)
+	}
+
+	rng.Shuffle(len(cities), func(i, j int) {
+		cities[i], cities[j] = cities[j], cities[i]
+	})
+
+	return cities[:n], nil
+}
@@ -0,0 +1,121 @@
+package worldmap
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compactTestWorld returns a small world to convert:
+//
+//	Bee --- Bar
+//	         |
+//	Baz --- Foo
+//	         |
+//	        Qux
+func compactTestWorld() World {
+	return World{
+		"Foo": Roads{Direction_North: "Bar", Direction_West: "Baz", Direction_South: "Qux"},
+		"Bar": Roads{Direction_South: "Foo", Direction_West: "Bee"},
+		"Baz": Roads{Direction_East: "Foo"},
+		"Qux": Roads{Direction_North: "Foo"},
+		"Bee": Roads{Direction_East: "Bar"},
+	}
+}
+
+func Test_NewCompact(t *testing.T) {
+	world := compactTestWorld()
+	c := NewCompact(world)
+
+	assert.Equal(t, 5, c.Len())
+	for i, name := range world.Cities() {
+		id, ok := c.ID(name)
+		assert.True(t, ok)
+		assert.Equal(t, CityID(i), id)
+		assert.Equal(t, name, c.Name(id))
+	}
+
+	_, ok := c.ID("Xen")
+	assert.False(t, ok)
+
+	foo, _ := c.ID("Foo")
+	bar, _ := c.ID("Bar")
+	baz, _ := c.ID("Baz")
+	qux, _ := c.ID("Qux")
+	assert.Equal(t, [4]CityID{bar, NoCity, qux, baz}, c.Neighbours(foo))
+
+	assert.Equal(t, world, c.World())
+}
+
+func Test_Compact_Destroy(t *testing.T) {
+	world := compactTestWorld()
+	c := NewCompact(world)
+
+	foo, _ := c.ID("Foo")
+	bar, _ := c.ID("Bar")
+	bee, _ := c.ID("Bee")
+	c.Destroy(foo)
+
+	assert.True(t, c.IsDestroyed(foo))
+	assert.False(t, c.IsDestroyed(bar))
+	assert.Equal(t, [4]CityID{NoCity, NoCity, NoCity, NoCity}, c.Neighbours(foo))
+	assert.Equal(t, [4]CityID{NoCity, NoCity, NoCity, bee}, c.Neighbours(bar))
+
+	world.DestroyCity("Foo")
+	assert.Equal(t, world, c.World())
+	assert.Equal(t, world["Bar"], c.Roads("Bar"))
+	assert.Empty(t, c.Roads("Foo"))
+}
+
+func Test_Compact_RemoveRoad(t *testing.T) {
+	world := compactTestWorld()
+	c := NewCompact(world)
+	clone := c.Clone()
+
+	foo, _ := c.ID("Foo")
+	bar, _ := c.ID("Bar")
+	baz, _ := c.ID("Baz")
+	qux, _ := c.ID("Qux")
+	bee, _ := c.ID("Bee")
+	c.RemoveRoad(foo, 0)
+
+	assert.Equal(t, [4]CityID{NoCity, NoCity, qux, baz}, c.Neighbours(foo))
+	assert.Equal(t, [4]CityID{NoCity, NoCity, NoCity, bee}, c.Neighbours(bar))
+
+	c.DestroyRoad("Baz", Direction_East)
+	world.DestroyRoad("Foo", Direction_North)
+	world.DestroyRoad("Baz", Direction_East)
+	assert.Equal(t, world, c.World())
+
+	// roads are only destroyed in c
+	assert.Equal(t, compactTestWorld(), clone.World())
+}
+
+func Test_Compact_Clone(t *testing.T) {
+	c := NewCompact(compactTestWorld())
+	clone := c.Clone()
+
+	clone.DestroyCity("Foo")
+
+	assert.Equal(t, compactTestWorld(), c.World())
+	assert.NotContains(t, clone.World(), "Foo")
+}
+
+func Test_Compact_RandomCities(t *testing.T) {
+	world := compactTestWorld()
+	c := NewCompact(world)
+
+	for seed := int64(0); seed < 10; seed++ {
+		expected, err := world.RandomCities(3, rand.New(rand.NewSource(seed)))
+		assert.Nil(t, err)
+
+		cities, err := c.RandomCities(3, rand.New(rand.NewSource(seed)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, cities)
+	}
+
+	c.DestroyCity("Foo")
+	_, err := c.RandomCities(5, rand.New(rand.NewSource(1)))
+	assert.NotNil(t, err)
+}
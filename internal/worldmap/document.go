@@ -0,0 +1,327 @@
+package worldmap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is the structure of map files in JSON and YAML formats. Unlike the line-based format, these formats can
+// hold the coordinates of cities.
+type document struct {
+	Metadata *documentMetadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Cities   []documentCity    `json:"cities" yaml:"cities"`
+}
+
+// documentMetadata is the structure of the metadata of JSON and YAML map files.
+type documentMetadata struct {
+	Name          string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Author        string            `json:"author,omitempty" yaml:"author,omitempty"`
+	Aliens        int               `json:"aliens,omitempty" yaml:"aliens,omitempty"`
+	MaxIterations int               `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+	Extra         map[string]string `json:"extra,omitempty" yaml:"extra,omitempty"`
+}
+
+// documentCity is the structure of the declaration of a city in JSON and YAML map files.
+type documentCity struct {
+	Name        string               `json:"name" yaml:"name"`
+	Roads       map[Direction]string `json:"roads,omitempty" yaml:"roads,omitempty"`
+	Coordinates *Coords              `json:"coordinates,omitempty" yaml:"coordinates,omitempty"`
+}
+
+// ParseJSON parses a map file in JSON format read from r. Such files hold an object with an optional "metadata"
+// object and a "cities" array, where every city has a "name", an optional "roads" object mapping directions to
+// destination cities and optional "coordinates" with "x" and "y" integer fields:
+//
+//	{
+//	  "metadata": {"name": "Tiny", "author": "Jane Doe", "aliens": 2, "max_iterations": 100, "extra": {"difficulty": "easy"}},
+//	  "cities": [
+//	    {"name": "Foo", "roads": {"north": "Bar"}, "coordinates": {"x": 0, "y": 0}},
+//	    {"name": "Bar", "coordinates": {"x": 0, "y": 1}}
+//	  ]
+//	}
+//
+// Roads are validated the same way as in the line-based format (see ReadFromFile), and the resulting world must be
+// consistent (see CheckConsistency). Coordinates, when given, must agree with the roads between cities, and no two
+// cities can share the same ones.
+func ParseJSON(r io.Reader) (*MapFile, error) {
+	doc := document{}
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("malformed JSON map file: %w", err)
+	}
+
+	return doc.mapFile()
+}
+
+// ParseYAML parses a map file in YAML format read from r. The structure of such files is the same as the one of JSON
+// map files (see ParseJSON).
+func ParseYAML(r io.Reader) (*MapFile, error) {
+	doc := document{}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("malformed YAML map file: %w", err)
+	}
+
+	return doc.mapFile()
+}
+
+// FormatJSON writes world to out as a JSON map file (see ParseJSON). Cities are written in the same order Format
+// would write them, along with their coordinates in opts.Layout, if any. Comments are not written, as JSON has no
+// place for them.
+func FormatJSON(out io.Writer, world World, opts FormatOptions) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(newDocument(world, opts))
+}
+
+// FormatYAML writes world to out as a YAML map file (see ParseYAML). Cities are written in the same order Format
+// would write them, along with their coordinates in opts.Layout, if any. Comments are not written.
+func FormatYAML(out io.Writer, world World, opts FormatOptions) error {
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(newDocument(world, opts)); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// newDocument creates the document describing world, according to opts.
+func newDocument(world World, opts FormatOptions) document {
+	doc := document{Cities: []documentCity{}}
+	if !opts.Metadata.IsZero() {
+		doc.Metadata = &documentMetadata{
+			Name:          opts.Metadata.Name,
+			Author:        opts.Metadata.Author,
+			Aliens:        opts.Metadata.Aliens,
+			MaxIterations: opts.Metadata.MaxIterations,
+			Extra:         opts.Metadata.Extra,
+		}
+	}
+
+	for _, c := range formatOrder(world, opts.Order) {
+		city := documentCity{Name: c}
+		if len(world[c]) > 0 {
+			city.Roads = make(map[Direction]string, len(world[c]))
+			for dir, dest := range world[c] {
+				city.Roads[dir] = dest
+			}
+		}
+		if pos, ok := opts.Layout[c]; ok {
+			city.Coordinates = &Coords{X: pos.X, Y: pos.Y}
+		}
+
+		doc.Cities = append(doc.Cities, city)
+	}
+
+	return doc
+}
+
+// mapFile validates the document and creates the MapFile it describes.
+func (d document) mapFile() (*MapFile, error) {
+	mapFile := &MapFile{World: World{}, Order: []string{}, Comments: Comments{Cities: map[string][]string{}}}
+
+	if d.Metadata != nil {
+		metadata, err := d.Metadata.metadata()
+		if err != nil {
+			return nil, err
+		}
+		mapFile.Metadata = metadata
+	}
+
+	declared := map[string]bool{}
+	mentionedOrder := []string{}
+	layout := Layout{}
+	for i, c := range d.Cities {
+		if c.Name == "" {
+			return nil, fmt.Errorf("city #%d has no name", i+1)
+		}
+
+		if !isValidCityName(c.Name) {
+			return nil, fmt.Errorf("malformed name of city #%d %q: names can't contain whitespace nor '='", i+1, c.Name)
+		}
+
+		if declared[c.Name] {
+			return nil, fmt.Errorf("city %s is declared more than once", c.Name)
+		}
+		declared[c.Name] = true
+		mapFile.Order = append(mapFile.Order, c.Name)
+
+		if _, ok := mapFile.World[c.Name]; !ok {
+			mapFile.World[c.Name] = Roads{}
+		}
+
+		if err := checkDirections(c); err != nil {
+			return nil, err
+		}
+
+		for _, dir := range Directions {
+			dest, ok := c.Roads[dir]
+			if !ok {
+				continue
+			}
+
+			if dest == "" {
+				return nil, fmt.Errorf("the road from %s going %s has no destination", c.Name, dir)
+			}
+
+			if !isValidCityName(dest) {
+				return nil, fmt.Errorf(
+					"malformed destination %q of the road from %s going %s: names can't contain whitespace nor '='",
+					dest, c.Name, dir,
+				)
+			}
+
+			if err := mapFile.World.addRoad(c.Name, dir, dest); err != nil {
+				return nil, fmt.Errorf("conflict in road declaration of city %s: %w", c.Name, err)
+			}
+
+			mentionedOrder = append(mentionedOrder, dest)
+		}
+
+		if c.Coordinates != nil {
+			layout[c.Name] = *c.Coordinates
+		}
+	}
+
+	for _, c := range mentionedOrder {
+		if !declared[c] {
+			declared[c] = true
+			mapFile.Order = append(mapFile.Order, c)
+		}
+	}
+
+	if err := checkCoordinates(mapFile.World, layout); err != nil {
+		return nil, err
+	}
+
+	if err := CheckConsistency(mapFile.World); err != nil {
+		return nil, err
+	}
+
+	if len(layout) > 0 {
+		mapFile.Layout = layout
+	}
+
+	return mapFile, nil
+}
+
+// isValidCityName reports whether name can be written to a line-based map file and read back, which is not the case
+// for names with whitespace or '=' in them.
+func isValidCityName(name string) bool {
+	return !strings.ContainsAny(name, " \t\n\r=")
+}
+
+// checkDirections checks that every road of city goes in a valid direction.
+func checkDirections(city documentCity) error {
+	dirs := make([]string, 0, len(city.Roads))
+	for dir := range city.Roads {
+		dirs = append(dirs, string(dir))
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		if _, err := Direction(dir).opposite(); err != nil {
+			return fmt.Errorf("bad direction in roads of city %s: %s", city.Name, dir)
+		}
+	}
+
+	return nil
+}
+
+// checkCoordinates checks that the coordinates of the cities in layout agree with the roads between them in world,
+// and that no two cities share the same coordinates.
+func checkCoordinates(world World, layout Layout) error {
+	grid := map[Coords]string{}
+	for _, c := range layout.Cities() {
+		pos := layout[c]
+		if other, occupied := grid[pos]; occupied {
+			return fmt.Errorf("bad coordinates: %s and %s are both placed at %s", other, c, pos)
+		}
+		grid[pos] = c
+
+		for _, dir := range Directions {
+			dest, ok := world[c][dir]
+			if !ok {
+				continue
+			}
+
+			destPos, ok := layout[dest]
+			if !ok {
+				continue
+			}
+
+			x, y, _ := nextCoords(pos.X, pos.Y, dir)
+			if destPos != (Coords{X: x, Y: y}) {
+				return fmt.Errorf(
+					"bad coordinates: %s is placed at %s, but the road going %s from %s at %s leads to (%d, %d)",
+					dest, destPos, dir, c, pos, x, y,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// metadata validates the document metadata and creates the Metadata it describes. Extra keys must be valid keys in
+// the line-based format, so that every map file can be converted to it.
+func (d documentMetadata) metadata() (Metadata, error) {
+	if d.Aliens < 0 {
+		return Metadata{}, fmt.Errorf("bad value for metadata key aliens: %d is not a number greater than 0", d.Aliens)
+	}
+
+	if d.MaxIterations < 0 {
+		return Metadata{}, fmt.Errorf("bad value for metadata key max_iterations: %d is not a number greater than 0", d.MaxIterations)
+	}
+
+	metadata := Metadata{
+		Name:          strings.TrimSpace(d.Name),
+		Author:        strings.TrimSpace(d.Author),
+		Aliens:        d.Aliens,
+		MaxIterations: d.MaxIterations,
+	}
+
+	if strings.Contains(metadata.Name, "\n") || strings.Contains(metadata.Author, "\n") {
+		return Metadata{}, errors.New("bad metadata: values can't span multiple lines")
+	}
+
+	keys := make([]string, 0, len(d.Extra))
+	for k := range d.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := d.Extra[k]
+		if k == "" || strings.ContainsAny(k, " \t\n") {
+			return Metadata{}, fmt.Errorf("malformed metadata key %q: keys can't be empty nor contain whitespace", k)
+		}
+
+		switch k {
+		case MetadataKey_Name, MetadataKey_Author, MetadataKey_Aliens, MetadataKey_MaxIterations:
+			return Metadata{}, fmt.Errorf("extra metadata key %s must be declared as a regular one", k)
+		}
+
+		v = strings.TrimSpace(v)
+		if v == "" || strings.Contains(v, "\n") {
+			return Metadata{}, fmt.Errorf("bad value for metadata key %s: values can't be empty nor span multiple lines", k)
+		}
+
+		if metadata.Extra == nil {
+			metadata.Extra = map[string]string{}
+		}
+		metadata.Extra[k] = v
+	}
+
+	return metadata, nil
+}
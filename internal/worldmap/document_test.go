@@ -0,0 +1,161 @@
+package worldmap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseJSON(t *testing.T) {
+	mapFile, err := ParseJSON(strings.NewReader(`{
+		"metadata": {"name": "Tiny", "author": "Jane Doe", "aliens": 2, "max_iterations": 100, "extra": {"difficulty": "easy"}},
+		"cities": [
+			{"name": "Foo", "roads": {"north": "Bar", "west": "Baz"}, "coordinates": {"x": 1, "y": 0}},
+			{"name": "Bar", "coordinates": {"x": 1, "y": 1}},
+			{"name": "Kaa"}
+		]
+	}`))
+	assert.Nil(t, err)
+
+	expectedWorld := World{
+		"Foo": Roads{Direction_North: "Bar", Direction_West: "Baz"},
+		"Bar": Roads{Direction_South: "Foo"},
+		"Baz": Roads{Direction_East: "Foo"},
+		"Kaa": Roads{},
+	}
+	assert.Equal(t, expectedWorld, mapFile.World)
+	assert.Equal(t, []string{"Foo", "Bar", "Kaa", "Baz"}, mapFile.Order)
+	assert.Equal(t, Layout{"Foo": {X: 1, Y: 0}, "Bar": {X: 1, Y: 1}}, mapFile.Layout)
+
+	expectedMetadata := Metadata{
+		Name:          "Tiny",
+		Author:        "Jane Doe",
+		Aliens:        2,
+		MaxIterations: 100,
+		Extra:         map[string]string{"difficulty": "easy"},
+	}
+	assert.Equal(t, expectedMetadata, mapFile.Metadata)
+}
+
+func Test_ParseYAML(t *testing.T) {
+	mapFile, err := ParseYAML(strings.NewReader(`
+metadata:
+  name: Tiny
+  max_iterations: 100
+cities:
+  - name: Foo
+    roads:
+      north: Bar
+      west: Baz
+  - name: Bar
+    coordinates: {x: 0, y: 1}
+`))
+	assert.Nil(t, err)
+
+	expectedWorld := World{
+		"Foo": Roads{Direction_North: "Bar", Direction_West: "Baz"},
+		"Bar": Roads{Direction_South: "Foo"},
+		"Baz": Roads{Direction_East: "Foo"},
+	}
+	assert.Equal(t, expectedWorld, mapFile.World)
+	assert.Equal(t, []string{"Foo", "Bar", "Baz"}, mapFile.Order)
+	assert.Equal(t, Layout{"Bar": {X: 0, Y: 1}}, mapFile.Layout)
+	assert.Equal(t, Metadata{Name: "Tiny", MaxIterations: 100}, mapFile.Metadata)
+}
+
+func Test_ParseJSON_errors(t *testing.T) {
+	testCases := map[string]string{
+		"malformed JSON":               `{"cities": [`,
+		"unknown field":                `{"cities": [], "countries": []}`,
+		"city without name":            `{"cities": [{"roads": {"north": "Bar"}}]}`,
+		"city declared twice":          `{"cities": [{"name": "Foo"}, {"name": "Foo"}]}`,
+		"unsupported direction":        `{"cities": [{"name": "Foo", "roads": {"southeast": "Bar"}}]}`,
+		"road without destination":     `{"cities": [{"name": "Foo", "roads": {"north": ""}}]}`,
+		"city name with whitespace":    `{"cities": [{"name": "New York"}]}`,
+		"city name with equals sign":   `{"cities": [{"name": "a=b"}]}`,
+		"destination with whitespace":  `{"cities": [{"name": "Foo", "roads": {"north": "New York"}}]}`,
+		"destination with equals sign": `{"cities": [{"name": "Foo", "roads": {"north": "a=b"}}]}`,
+		"conflicting road declaration": `{"cities": [{"name": "Foo", "roads": {"north": "Bar"}}, {"name": "Bar", "roads": {"south": "Baz"}}]}`,
+		"inconsistent world":           `{"cities": [{"name": "Foo", "roads": {"east": "Bar"}}, {"name": "Bar", "roads": {"east": "Foo"}}]}`,
+		"coordinates out of place":     `{"cities": [{"name": "Foo", "roads": {"east": "Bar"}, "coordinates": {"x": 0, "y": 0}}, {"name": "Bar", "coordinates": {"x": 0, "y": 1}}]}`,
+		"overlapping coordinates":      `{"cities": [{"name": "Foo", "coordinates": {"x": 0, "y": 0}}, {"name": "Bar", "coordinates": {"x": 0, "y": 0}}]}`,
+		"negative aliens":              `{"metadata": {"aliens": -1}, "cities": []}`,
+		"malformed extra key":          `{"metadata": {"extra": {"a key": "value"}}, "cities": []}`,
+		"extra key with meaning":       `{"metadata": {"extra": {"author": "Me"}}, "cities": []}`,
+	}
+
+	for name, contents := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseJSON(strings.NewReader(contents))
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func Test_FormatJSON_roundTrip(t *testing.T) {
+	mapFile, err := Parse(strings.NewReader("@name Tiny\n@difficulty easy\nFoo north=Bar west=Baz\nBar west=Bee\nKaa"))
+	assert.Nil(t, err)
+
+	layout, err := NewLayout(mapFile.World)
+	assert.Nil(t, err)
+
+	opts := FormatOptions{Order: mapFile.Order, Metadata: mapFile.Metadata, Layout: layout}
+	formats := map[string]struct {
+		format func(*bytes.Buffer) error
+		parse  func(*bytes.Buffer) (*MapFile, error)
+	}{
+		"json": {
+			format: func(out *bytes.Buffer) error { return FormatJSON(out, mapFile.World, opts) },
+			parse:  func(in *bytes.Buffer) (*MapFile, error) { return ParseJSON(in) },
+		},
+		"yaml": {
+			format: func(out *bytes.Buffer) error { return FormatYAML(out, mapFile.World, opts) },
+			parse:  func(in *bytes.Buffer) (*MapFile, error) { return ParseYAML(in) },
+		},
+	}
+
+	for name, f := range formats {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := f.format(out)
+			assert.Nil(t, err)
+
+			reparsed, err := f.parse(out)
+			assert.Nil(t, err)
+
+			assert.Equal(t, mapFile.World, reparsed.World)
+			assert.Equal(t, mapFile.Order, reparsed.Order)
+			assert.Equal(t, mapFile.Metadata, reparsed.Metadata)
+			assert.Equal(t, layout, reparsed.Layout)
+		})
+	}
+}
+
+func Test_FormatYAML(t *testing.T) {
+	world := World{
+		"Foo": Roads{Direction_North: "Bar"},
+		"Bar": Roads{Direction_South: "Foo"},
+	}
+
+	out := &bytes.Buffer{}
+	err := FormatYAML(out, world, FormatOptions{Metadata: Metadata{Aliens: 2}, Layout: Layout{"Foo": {X: 0, Y: 0}}})
+	assert.Nil(t, err)
+
+	expected := `metadata:
+  aliens: 2
+cities:
+  - name: Bar
+    roads:
+      south: Foo
+  - name: Foo
+    roads:
+      north: Bar
+    coordinates:
+      x: 0
+      "y": 0
+`
+	assert.Equal(t, expected, out.String())
+}
@@ -0,0 +1,118 @@
+package worldmap
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// EncodeOptions tweaks how worlds are encoded in graphical formats.
+type EncodeOptions struct {
+	// Layout places cities in a grid. If nil, it is computed from the world being encoded. A Layout computed before
+	// an invasion can be passed to highlight the cities destroyed since then: cities in Layout that are not part of the
+	// world anymore are drawn as destroyed.
+	Layout Layout
+	// Aliens optionally maps alien names to the cities they are at, so their positions can be overlaid on the world.
+	// The Cities of an aliens.Tracker can be used as is.
+	Aliens map[string]string
+}
+
+// dotScale is the distance, in inches, between neighbouring cities in DOT output.
+const dotScale = 1.5
+
+// EncodeDOT writes world to out as an undirected graph in Graphviz DOT format. Cities are nodes pinned to the
+// position given by the layout in opts, so the graph is meant to be rendered with the neato engine (which is set as
+// the default layout engine of the graph). Cities with aliens in them list their names and are outlined in red, and
+// destroyed cities are filled in black.
+func EncodeDOT(out io.Writer, world World, opts EncodeOptions) error {
+	layout, err := encodingLayout(world, opts)
+	if err != nil {
+		return err
+	}
+
+	aliensAt := aliensByCity(opts.Aliens)
+
+	builder := strings.Builder{}
+	builder.WriteString("graph world {\n")
+	builder.WriteString("\tgraph [layout=neato];\n")
+	builder.WriteString("\tnode [shape=box, style=filled, fillcolor=lightgrey];\n")
+
+	for _, c := range layout.Cities() {
+		pos := layout[c]
+		attrs := []string{fmt.Sprintf("pos=\"%g,%g!\"", float64(pos.X)*dotScale, float64(pos.Y)*dotScale)}
+
+		if _, exists := world[c]; !exists {
+			attrs = append(attrs, "fillcolor=black", "fontcolor=white")
+		}
+
+		if aliens := aliensAt[c]; len(aliens) > 0 {
+			attrs = append(attrs, "color=red", "penwidth=2", "label="+dotID(c+"\n"+strings.Join(aliens, "\n")))
+		}
+
+		builder.WriteString(fmt.Sprintf("\t%s [%s];\n", dotID(c), strings.Join(attrs, ", ")))
+	}
+
+	for _, r := range roads(world) {
+		builder.WriteString(fmt.Sprintf("\t%s -- %s;\n", dotID(r[0]), dotID(r[1])))
+	}
+
+	builder.WriteString("}\n")
+
+	_, err = io.WriteString(out, builder.String())
+
+	return err
+}
+
+// dotID quotes s so it can be used as an ID in DOT format.
+func dotID(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+
+	return `"` + s + `"`
+}
+
+// encodingLayout returns the layout in opts or, if there is none, computes one for world.
+func encodingLayout(world World, opts EncodeOptions) (Layout, error) {
+	if opts.Layout != nil {
+		return opts.Layout, nil
+	}
+
+	return NewLayout(world)
+}
+
+// aliensByCity inverts the given map of alien positions, returning the names of the aliens at each city in
+// alphabetical order.
+func aliensByCity(positions map[string]string) map[string][]string {
+	aliensAt := map[string][]string{}
+	for a, c := range positions {
+		aliensAt[c] = append(aliensAt[c], a)
+	}
+
+	for _, aliens := range aliensAt {
+		sort.Strings(aliens)
+	}
+
+	return aliensAt
+}
+
+// roads returns every road in world once, as pairs of the cities at both ends. Roads are sorted by their first city
+// and then following the order in Directions.
+func roads(world World) [][2]string {
+	seen := map[[2]string]bool{}
+	pairs := [][2]string{}
+	for _, c := range world.Cities() {
+		for _, dir := range Directions {
+			dest, ok := world[c][dir]
+			if !ok || seen[[2]string{dest, c}] {
+				continue
+			}
+
+			seen[[2]string{c, dest}] = true
+			pairs = append(pairs, [2]string{c, dest})
+		}
+	}
+
+	return pairs
+}
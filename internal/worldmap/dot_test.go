@@ -0,0 +1,83 @@
+package worldmap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncodeDOT(t *testing.T) {
+	// Bar
+	//  |
+	// Foo --- Baz
+	world := World{
+		"Foo": Roads{Direction_North: "Bar", Direction_East: "Baz"},
+		"Bar": Roads{Direction_South: "Foo"},
+		"Baz": Roads{Direction_West: "Foo"},
+	}
+
+	testCases := map[string]struct {
+		world         World
+		opts          EncodeOptions
+		cityToDestroy string
+		expected      string
+	}{
+		"plain world": {
+			opts: EncodeOptions{},
+			expected: `graph world {
+	graph [layout=neato];
+	node [shape=box, style=filled, fillcolor=lightgrey];
+	"Bar" [pos="0,1.5!"];
+	"Baz" [pos="1.5,0!"];
+	"Foo" [pos="0,0!"];
+	"Bar" -- "Foo";
+	"Baz" -- "Foo";
+}
+`,
+		},
+		"aliens and destroyed cities": {
+			opts: EncodeOptions{
+				Aliens: map[string]string{"alien 1": "Baz", "alien 0": "Baz"},
+			},
+			cityToDestroy: "Foo",
+			expected: `graph world {
+	graph [layout=neato];
+	node [shape=box, style=filled, fillcolor=lightgrey];
+	"Bar" [pos="0,1.5!"];
+	"Baz" [pos="1.5,0!", color=red, penwidth=2, label="Baz\nalien 0\nalien 1"];
+	"Foo" [pos="0,0!", fillcolor=black, fontcolor=white];
+}
+`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			layout, err := NewLayout(world)
+			assert.Nil(t, err)
+
+			w := world.Copy()
+			if tc.cityToDestroy != "" {
+				w.DestroyCity(tc.cityToDestroy)
+			}
+
+			tc.opts.Layout = layout
+			out := &bytes.Buffer{}
+			err = EncodeDOT(out, w, tc.opts)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func Test_EncodeDOT_inconsistentWorld(t *testing.T) {
+	world := World{
+		"Foo": Roads{Direction_East: "Bar"},
+		"Bar": Roads{Direction_East: "Foo", Direction_West: "Foo"},
+	}
+
+	err := EncodeDOT(&bytes.Buffer{}, world, EncodeOptions{})
+	assert.Error(t, err)
+}
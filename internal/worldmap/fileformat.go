@@ -0,0 +1,95 @@
+package worldmap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// FileFormat is one of the formats map files can be written in.
+type FileFormat string
+
+const (
+	// FileFormat_Map is the line-based format described in ReadFromFile.
+	FileFormat_Map FileFormat = "map"
+	// FileFormat_JSON is the JSON format described in ParseJSON.
+	FileFormat_JSON FileFormat = "json"
+	// FileFormat_YAML is the YAML format described in ParseYAML.
+	FileFormat_YAML FileFormat = "yaml"
+	// FileFormat_Grid is the text grid format described in ReadGrid. Map files can't be written in this format, but
+	// worlds can be drawn like this with render.ASCII.
+	FileFormat_Grid FileFormat = "grid"
+)
+
+// FileFormats lists every format map files can be read from.
+var FileFormats = []FileFormat{FileFormat_Map, FileFormat_JSON, FileFormat_YAML, FileFormat_Grid}
+
+// DetectFileFormat guesses the format of the map file at path with the given contents. The extension of the file is
+// checked first: ".json" files are JSON, ".yaml" or ".yml" files are YAML and ".grid" files are grids. Otherwise, the
+// first line that is neither blank nor a comment is checked: JSON files start with "{", and YAML files start with "---"
+// or one of their top-level keys. Anything else is considered to be in the line-based format.
+func DetectFileFormat(path string, contents []byte) FileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FileFormat_JSON
+	case ".yaml", ".yml":
+		return FileFormat_YAML
+	case ".grid":
+		return FileFormat_Grid
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || isCommentLine(line) {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "{"):
+			return FileFormat_JSON
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "cities:"), strings.HasPrefix(line, "metadata:"):
+			return FileFormat_YAML
+		default:
+			return FileFormat_Map
+		}
+	}
+
+	return FileFormat_Map
+}
+
+// ParseFormat parses a map file in the given format read from r.
+func ParseFormat(r io.Reader, format FileFormat) (*MapFile, error) {
+	switch format {
+	case FileFormat_Map:
+		return Parse(r)
+	case FileFormat_JSON:
+		return ParseJSON(r)
+	case FileFormat_YAML:
+		return ParseYAML(r)
+	case FileFormat_Grid:
+		return ReadGrid(r)
+	default:
+		return nil, fmt.Errorf("unknown map file format %s", format)
+	}
+}
+
+// FormatAs writes world to out as a map file in the given format. The grid format is not supported.
+func FormatAs(out io.Writer, world World, format FileFormat, opts FormatOptions) error {
+	switch format {
+	case FileFormat_Map:
+		return Format(out, world, opts)
+	case FileFormat_JSON:
+		return FormatJSON(out, world, opts)
+	case FileFormat_YAML:
+		return FormatYAML(out, world, opts)
+	case FileFormat_Grid:
+		return errors.New("map files can't be written in grid format")
+	default:
+		return fmt.Errorf("unknown map file format %s", format)
+	}
+}
@@ -0,0 +1,31 @@
+package worldmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DetectFileFormat(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		contents string
+		expected FileFormat
+	}{
+		"json extension": {path: "world.json", contents: "Foo north=Bar", expected: FileFormat_JSON},
+		"yaml extension": {path: "world.YAML", contents: "", expected: FileFormat_YAML},
+		"yml extension":  {path: "world.yml", contents: "", expected: FileFormat_YAML},
+		"grid extension": {path: "world.grid", contents: "Foo - Bar", expected: FileFormat_Grid},
+		"json contents":  {path: "world.map", contents: "\n  {\"cities\": []}", expected: FileFormat_JSON},
+		"yaml contents":  {path: "world", contents: "# a world\ncities:\n  - name: Foo", expected: FileFormat_YAML},
+		"yaml document":  {path: "world.txt", contents: "---\nmetadata:\n  name: Tiny", expected: FileFormat_YAML},
+		"line-based":     {path: "world.json.bak", contents: "@name Tiny\nFoo north=Bar", expected: FileFormat_Map},
+		"empty":          {path: "world", contents: "", expected: FileFormat_Map},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DetectFileFormat(tc.path, []byte(tc.contents)))
+		})
+	}
+}
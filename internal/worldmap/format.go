@@ -0,0 +1,88 @@
+package worldmap
+
+import (
+	"bufio"
+	"io"
+)
+
+// FormatOptions tweaks how worlds are written in map file format.
+type FormatOptions struct {
+	// Order lists cities in the order they must be written, like the order they were declared in in the original map
+	// file (see MapFile). Cities not in Order are written after the ones in it, in alphabetical order. If empty, all
+	// cities are written in alphabetical order.
+	Order []string
+	// Metadata is written as a header block before the cities. Nothing is written if it is empty.
+	Metadata Metadata
+	// Comments, if not nil, are written in the same place relative to the cities as they were found in the original
+	// map file (see MapFile). Comments about cities that are not part of the world are dropped.
+	Comments *Comments
+	// Layout holds the coordinates to write along the cities in formats that support them, like JSON and YAML. It is
+	// ignored by Format.
+	Layout Layout
+}
+
+// Format writes world to out in canonical map file format: every city is declared in its own line, followed by all
+// of its roads in the order given by Directions. Cities are written in alphabetical order unless opts says otherwise.
+// The header block, made of the metadata followed by the comments in the header, is separated from the cities by a
+// blank line, and so are the comments at the end of the file. No other blank lines are written.
+// The output only depends on the contents of world and opts, so it can be safely compared with other outputs.
+func Format(out io.Writer, world World, opts FormatOptions) error {
+	comments := Comments{}
+	if opts.Comments != nil {
+		comments = *opts.Comments
+	}
+
+	w := bufio.NewWriter(out)
+	opts.Metadata.write(w)
+	writeLines(w, comments.Header)
+	if (!opts.Metadata.IsZero() || len(comments.Header) > 0) && len(world) > 0 {
+		w.WriteString("\n")
+	}
+
+	for _, c := range formatOrder(world, opts.Order) {
+		writeLines(w, comments.Cities[c])
+		w.WriteString(c)
+		roads := world[c]
+		for _, dir := range Directions {
+			if dest, ok := roads[dir]; ok {
+				w.WriteString(" " + string(dir) + "=" + dest)
+			}
+		}
+		w.WriteString("\n")
+	}
+
+	if len(comments.Footer) > 0 && len(world) > 0 {
+		w.WriteString("\n")
+	}
+	writeLines(w, comments.Footer)
+
+	return w.Flush()
+}
+
+// writeLines writes every line in lines to w.
+func writeLines(w *bufio.Writer, lines []string) {
+	for _, l := range lines {
+		w.WriteString(l + "\n")
+	}
+}
+
+// formatOrder returns the cities of world in the order they must be written: the ones in order first, followed by
+// the rest in alphabetical order. Cities in order that are not part of world are skipped.
+func formatOrder(world World, order []string) []string {
+	cities := make([]string, 0, len(world))
+	written := make(map[string]bool, len(world))
+	for _, c := range order {
+		if _, exists := world[c]; exists && !written[c] {
+			written[c] = true
+			cities = append(cities, c)
+		}
+	}
+
+	for _, c := range world.Cities() {
+		if !written[c] {
+			cities = append(cities, c)
+		}
+	}
+
+	return cities
+}
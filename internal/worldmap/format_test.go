@@ -0,0 +1,110 @@
+package worldmap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Format(t *testing.T) {
+	world := World{
+		"Foo": Roads{
+			Direction_West:  "Baz",
+			Direction_South: "Qu-ux",
+			Direction_North: "Bar",
+		},
+		"Bar": Roads{
+			Direction_South: "Foo",
+		},
+		"Baz": Roads{
+			Direction_East: "Foo",
+		},
+		"Qu-ux": Roads{
+			Direction_North: "Foo",
+		},
+		"Kaa": Roads{},
+	}
+
+	testCases := map[string]struct {
+		opts     FormatOptions
+		expected string
+	}{
+		"canonical": {
+			opts:     FormatOptions{},
+			expected: "Bar south=Foo\nBaz east=Foo\nFoo north=Bar south=Qu-ux west=Baz\nKaa\nQu-ux north=Foo\n",
+		},
+		"custom order": {
+			opts:     FormatOptions{Order: []string{"Foo", "Qu-ux", "Xen", "Foo", "Baz"}},
+			expected: "Foo north=Bar south=Qu-ux west=Baz\nQu-ux north=Foo\nBaz east=Foo\nBar south=Foo\nKaa\n",
+		},
+		"metadata and comments": {
+			opts: FormatOptions{
+				Metadata: Metadata{Name: "Tiny", Aliens: 2, Extra: map[string]string{"difficulty": "easy"}},
+				Comments: &Comments{
+					Header: []string{"# header"},
+					Cities: map[string][]string{"Foo": {"# about Foo"}, "Xen": {"# about Xen"}},
+					Footer: []string{"# footer"},
+				},
+			},
+			expected: "@name Tiny\n@aliens 2\n@difficulty easy\n# header\n\n" +
+				"Bar south=Foo\nBaz east=Foo\n# about Foo\nFoo north=Bar south=Qu-ux west=Baz\nKaa\nQu-ux north=Foo\n" +
+				"\n# footer\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := Format(out, world, tc.opts)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func Test_Format_roundTrip(t *testing.T) {
+	mapFile, err := Parse(bytes.NewBufferString("Foo north=Bar west=Baz south=Qu-ux\nBar south=Foo west=Bee"))
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	err = Format(out, mapFile.World, FormatOptions{Order: mapFile.Order})
+	assert.Nil(t, err)
+
+	expected := "Foo north=Bar south=Qu-ux west=Baz\nBar south=Foo west=Bee\nBaz east=Foo\nQu-ux north=Foo\nBee east=Bar\n"
+	assert.Equal(t, expected, out.String())
+
+	// formatting is idempotent
+	reparsed, err := Parse(bytes.NewBufferString(out.String()))
+	assert.Nil(t, err)
+	assert.Equal(t, mapFile.World, reparsed.World)
+
+	again := &bytes.Buffer{}
+	err = Format(again, reparsed.World, FormatOptions{Order: reparsed.Order})
+	assert.Nil(t, err)
+	assert.Equal(t, out.String(), again.String())
+}
+
+func Test_Format_roundTripWithComments(t *testing.T) {
+	mapFile, err := Parse(bytes.NewBufferString(
+		"# header\n@author Me\n# about Foo\nFoo north=Bar\n\n\n# about Bar\nBar south=Foo\n# footer\n",
+	))
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	err = Format(out, mapFile.World, FormatOptions{Metadata: mapFile.Metadata, Comments: &mapFile.Comments})
+	assert.Nil(t, err)
+
+	expected := "@author Me\n# header\n\n# about Bar\nBar south=Foo\n# about Foo\nFoo north=Bar\n\n# footer\n"
+	assert.Equal(t, expected, out.String())
+
+	// formatting is idempotent
+	reparsed, err := Parse(bytes.NewBufferString(out.String()))
+	assert.Nil(t, err)
+
+	again := &bytes.Buffer{}
+	err = Format(again, reparsed.World, FormatOptions{Metadata: reparsed.Metadata, Comments: &reparsed.Comments})
+	assert.Nil(t, err)
+	assert.Equal(t, out.String(), again.String())
+}
@@ -0,0 +1,229 @@
+package worldmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markers used in grid map files
+const (
+	gridEmptyMarker          = "."
+	gridCraterMarker         = '*'
+	gridEastWestRoadMarker   = "-"
+	gridNorthSouthRoadMarker = '|'
+)
+
+// gridCell is a cell of a row of a grid map file. It spans from column start to column end (not included) of its line.
+type gridCell struct {
+	// city is the name of the city in the cell, or empty if there is none.
+	city  string
+	start int
+	end   int
+}
+
+// gridRow is a row of cities of a grid map file.
+type gridRow struct {
+	lineNum int
+	cells   []gridCell
+	// eastRoads tells, for every cell but the last one, whether there is a road to the next cell.
+	eastRoads []bool
+}
+
+// gridRoads is a line of a grid map file declaring roads between the rows of cities above and below it.
+type gridRoads struct {
+	lineNum int
+	// columns holds the columns of the line where roads are drawn.
+	columns []int
+}
+
+// ReadGrid reads a map drawn as a text grid from r, like the ones drawn by "invasim render". Cities are laid out in
+// rows, where every cell holds either the name of a city or a "." if it is empty. Cells are separated by spaces, and
+// a "-" between two cities declares a road between them. Rows can be separated by a line with "|" characters, each
+// one declaring a road between the cities right above and below it:
+//
+//	Bee - Bar
+//	      |
+//	Baz - Foo
+//	      |
+//	.     Qu-ux
+//
+// Cities in the same position of their rows are in the same column of the grid, so names are usually padded to align
+// the rows, but it's not required. Cells made of "*" are considered to be empty too, as they are drawn in place of
+// destroyed cities. Blank lines and lines starting with "#" are ignored.
+//
+// The resulting world is consistent by construction. The returned MapFile holds the position of every city in the
+// grid, and cities are ordered from left to right and from top to bottom.
+func ReadGrid(r io.Reader) (*MapFile, error) {
+	rows := []gridRow{}
+	// roads[i] holds the roads between rows[i] and rows[i+1]
+	roads := map[int]gridRoads{}
+
+	lineNum := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " ")
+		lineNum++
+
+		if line == "" || isCommentLine(line) {
+			continue
+		}
+
+		if isGridRoadsLine(line) {
+			if _, exists := roads[len(rows)-1]; exists || len(rows) == 0 {
+				return nil, fmt.Errorf("unexpected roads at line %d: roads must be declared between two rows of cities", lineNum)
+			}
+
+			columns := []int{}
+			for i, c := range line {
+				if c == gridNorthSouthRoadMarker {
+					columns = append(columns, i)
+				}
+			}
+			roads[len(rows)-1] = gridRoads{lineNum: lineNum, columns: columns}
+
+			continue
+		}
+
+		row, err := parseGridRow(line, lineNum)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if last, exists := roads[len(rows)-1]; exists {
+		return nil, fmt.Errorf("unexpected roads at line %d: roads must be declared between two rows of cities", last.lineNum)
+	}
+
+	mapFile := &MapFile{World: World{}, Order: []string{}, Comments: Comments{Cities: map[string][]string{}}, Layout: Layout{}}
+	declaredAt := map[string]int{}
+	for i, row := range rows {
+		for x, cell := range row.cells {
+			if cell.city == "" {
+				continue
+			}
+
+			if prev, declared := declaredAt[cell.city]; declared {
+				return nil, fmt.Errorf("city %s at line %d is already placed at line %d", cell.city, row.lineNum, prev)
+			}
+			declaredAt[cell.city] = row.lineNum
+
+			mapFile.World[cell.city] = Roads{}
+			mapFile.Order = append(mapFile.Order, cell.city)
+			mapFile.Layout[cell.city] = Coords{X: x, Y: len(rows) - 1 - i}
+		}
+	}
+
+	for i, row := range rows {
+		for x, hasRoad := range row.eastRoads {
+			if hasRoad {
+				_ = mapFile.World.addRoad(row.cells[x].city, Direction_East, row.cells[x+1].city)
+			}
+		}
+
+		below, ok := roads[i]
+		if !ok {
+			continue
+		}
+
+		for _, column := range below.columns {
+			north, northX := row.cellAt(column)
+			south, southX := rows[i+1].cellAt(column)
+			if north == "" || south == "" {
+				return nil, fmt.Errorf("bad road at line %d, column %d: it doesn't connect two cities", below.lineNum, column+1)
+			}
+
+			if northX != southX {
+				return nil, fmt.Errorf(
+					"bad road at line %d, column %d: %s and %s are not in the same column of the grid",
+					below.lineNum, column+1, north, south,
+				)
+			}
+
+			_ = mapFile.World.addRoad(north, Direction_South, south)
+		}
+	}
+
+	if len(mapFile.Layout) == 0 {
+		mapFile.Layout = nil
+	}
+
+	return mapFile, nil
+}
+
+// isGridRoadsLine reports whether line only declares roads between rows of a grid map file.
+func isGridRoadsLine(line string) bool {
+	return strings.Trim(line, " "+string(gridNorthSouthRoadMarker)) == ""
+}
+
+// parseGridRow parses a line of a grid map file holding a row of cities.
+func parseGridRow(line string, lineNum int) (gridRow, error) {
+	row := gridRow{lineNum: lineNum}
+	pendingRoad, roadColumn := false, 0
+	for start := 0; start < len(line); {
+		if line[start] == ' ' {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(line) && line[end] != ' ' {
+			end++
+		}
+		token := line[start:end]
+
+		if token == gridEastWestRoadMarker {
+			if pendingRoad || len(row.cells) == 0 || row.cells[len(row.cells)-1].city == "" {
+				return gridRow{}, fmt.Errorf("bad road at line %d, column %d: it doesn't connect two cities", lineNum, start+1)
+			}
+
+			pendingRoad, roadColumn = true, start
+			start = end
+			continue
+		}
+
+		if strings.ContainsAny(token, "=|") {
+			return gridRow{}, fmt.Errorf("bad city name at line %d, column %d: %s", lineNum, start+1, token)
+		}
+
+		city := token
+		if city == gridEmptyMarker || strings.Trim(city, string(gridCraterMarker)) == "" {
+			city = ""
+		}
+
+		if pendingRoad && city == "" {
+			return gridRow{}, fmt.Errorf("bad road at line %d, column %d: it doesn't connect two cities", lineNum, roadColumn+1)
+		}
+
+		if len(row.cells) > 0 {
+			row.eastRoads = append(row.eastRoads, pendingRoad)
+		}
+		row.cells = append(row.cells, gridCell{city: city, start: start, end: end})
+		pendingRoad = false
+		start = end
+	}
+
+	if pendingRoad {
+		return gridRow{}, fmt.Errorf("bad road at line %d, column %d: it doesn't connect two cities", lineNum, roadColumn+1)
+	}
+
+	return row, nil
+}
+
+// cellAt returns the city in the cell of the row that spans column, along with the position of the cell in the row.
+// An empty city is returned if there is no city there.
+func (r gridRow) cellAt(column int) (string, int) {
+	for x, cell := range r.cells {
+		if column >= cell.start && column < cell.end {
+			return cell.city, x
+		}
+	}
+
+	return "", -1
+}
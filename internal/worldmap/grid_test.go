@@ -0,0 +1,73 @@
+package worldmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReadGrid(t *testing.T) {
+	grid := "" +
+		"# Foo is the capital\n" +
+		"Bee   - Bar\n" +
+		"        |\n" +
+		"Baz   - Foo - Kaa\n" +
+		"        |\n" +
+		"\n" +
+		".       Qu-ux ***\n" +
+		"Xen\n"
+
+	mapFile, err := ReadGrid(strings.NewReader(grid))
+	assert.Nil(t, err)
+
+	expectedWorld := World{
+		"Bee":   Roads{Direction_East: "Bar"},
+		"Bar":   Roads{Direction_West: "Bee", Direction_South: "Foo"},
+		"Baz":   Roads{Direction_East: "Foo"},
+		"Foo":   Roads{Direction_North: "Bar", Direction_West: "Baz", Direction_East: "Kaa", Direction_South: "Qu-ux"},
+		"Kaa":   Roads{Direction_West: "Foo"},
+		"Qu-ux": Roads{Direction_North: "Foo"},
+		"Xen":   Roads{},
+	}
+	assert.Equal(t, expectedWorld, mapFile.World)
+	assert.Equal(t, []string{"Bee", "Bar", "Baz", "Foo", "Kaa", "Qu-ux", "Xen"}, mapFile.Order)
+
+	expectedLayout := Layout{
+		"Bee":   {X: 0, Y: 3},
+		"Bar":   {X: 1, Y: 3},
+		"Baz":   {X: 0, Y: 2},
+		"Foo":   {X: 1, Y: 2},
+		"Kaa":   {X: 2, Y: 2},
+		"Qu-ux": {X: 1, Y: 1},
+		"Xen":   {X: 0, Y: 0},
+	}
+	assert.Equal(t, expectedLayout, mapFile.Layout)
+
+	assert.Nil(t, CheckConsistency(mapFile.World))
+}
+
+func Test_ReadGrid_errors(t *testing.T) {
+	testCases := map[string]string{
+		"road to an empty cell":        "Foo - .",
+		"road at the end of a row":     "Foo - Bar -",
+		"road at the start of a row":   "- Foo",
+		"consecutive roads":            "Foo - - Bar",
+		"vertical road to nowhere":     "Foo\n |\n.",
+		"vertical road off the cells":  "Foo\n    |\nBar",
+		"misaligned vertical road":     "Foo Bar\n    |\n    . Baz",
+		"roads before the first row":   "|\nFoo",
+		"roads after the last row":     "Foo\n|",
+		"consecutive rows of roads":    "Foo\n|\n|\nBar",
+		"city placed twice":            "Foo - Bar\nFoo",
+		"road declaration in the cell": "Foo north=Bar",
+	}
+
+	for name, grid := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadGrid(strings.NewReader(grid))
+
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package worldmap
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Coords is a tuple that expresses the position of a city in a grid representation of a world. X grows eastwards and
+// Y grows northwards.
+type Coords struct {
+	X int `json:"x" yaml:"x"`
+	Y int `json:"y" yaml:"y"`
+}
+
+// String implements the Stringer interface.
+func (c Coords) String() string {
+	return fmt.Sprintf("(%d, %d)", c.X, c.Y)
+}
+
+// Layout maps the cities of a world to their positions in a grid representation of that world.
+type Layout map[string]Coords
+
+// NewLayout computes the position of every city of world in a grid. Connected cities are placed according to the
+// directions of the roads between them, while disconnected parts of the world (islands) are laid out from west to
+// east, leaving an empty column between them. The bottom-left corner of each island sits at Y = 0 and the westernmost
+// island starts at X = 0.
+//
+// The layout is deterministic: islands are laid out in the alphabetical order of their first city. An error is
+// returned if world is not consistent (see CheckConsistency).
+func NewLayout(world World) (Layout, error) {
+	islands, err := layoutIslands(world)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	layout := Layout{}
+	offsetX := 0
+	for _, island := range islands {
+		min, max := island.Bounds()
+		for c, pos := range island {
+			layout[c] = Coords{X: pos.X - min.X + offsetX, Y: pos.Y - min.Y}
+		}
+
+		offsetX += max.X - min.X + 2
+	}
+
+	return layout, nil
+}
+
+// Bounds returns the smallest and largest coordinates in the Layout, i.e. the bottom-left and top-right corners of the
+// smallest rectangle containing every city.
+func (l Layout) Bounds() (Coords, Coords) {
+	first := true
+	var min, max Coords
+	for _, pos := range l {
+		if first {
+			min, max = pos, pos
+			first = false
+			continue
+		}
+
+		if pos.X < min.X {
+			min.X = pos.X
+		}
+		if pos.Y < min.Y {
+			min.Y = pos.Y
+		}
+		if pos.X > max.X {
+			max.X = pos.X
+		}
+		if pos.Y > max.Y {
+			max.Y = pos.Y
+		}
+	}
+
+	return min, max
+}
+
+// Cities returns the names of the cities in the Layout in alphabetical order.
+func (l Layout) Cities() []string {
+	cities := make([]string, 0, len(l))
+	for c := range l {
+		cities = append(cities, c)
+	}
+	sort.Strings(cities)
+
+	return cities
+}
+
+// Grid returns a view of the Layout as a grid, mapping every occupied position to the city placed there.
+func (l Layout) Grid() map[Coords]string {
+	grid := make(map[Coords]string, len(l))
+	for c, pos := range l {
+		grid[pos] = c
+	}
+
+	return grid
+}
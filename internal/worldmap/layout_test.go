@@ -0,0 +1,122 @@
+package worldmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewLayout(t *testing.T) {
+	testCases := map[string]struct {
+		world          World
+		expectedLayout Layout
+		expectsError   bool
+	}{
+		// Bee --- Bar
+		//          |
+		// Baz --- Foo
+		//          |
+		//         Qu-ux
+		"single island": {
+			world: World{
+				"Foo": Roads{
+					Direction_North: "Bar",
+					Direction_West:  "Baz",
+					Direction_South: "Qu-ux",
+				},
+				"Bar": Roads{
+					Direction_South: "Foo",
+					Direction_West:  "Bee",
+				},
+				"Baz": Roads{
+					Direction_East: "Foo",
+				},
+				"Qu-ux": Roads{
+					Direction_North: "Foo",
+				},
+				"Bee": Roads{
+					Direction_East: "Bar",
+				},
+			},
+			expectedLayout: Layout{
+				"Bee":   {X: 0, Y: 2},
+				"Bar":   {X: 1, Y: 2},
+				"Baz":   {X: 0, Y: 1},
+				"Foo":   {X: 1, Y: 1},
+				"Qu-ux": {X: 1, Y: 0},
+			},
+			expectsError: false,
+		},
+		// Bar         Kaa
+		//  |           |
+		// Foo         Baz --- Xen
+		"several islands": {
+			world: World{
+				"Foo": Roads{Direction_North: "Bar"},
+				"Bar": Roads{Direction_South: "Foo"},
+				"Baz": Roads{Direction_North: "Kaa", Direction_East: "Xen"},
+				"Kaa": Roads{Direction_South: "Baz"},
+				"Xen": Roads{Direction_West: "Baz"},
+			},
+			expectedLayout: Layout{
+				"Bar": {X: 0, Y: 1},
+				"Foo": {X: 0, Y: 0},
+				"Kaa": {X: 2, Y: 1},
+				"Baz": {X: 2, Y: 0},
+				"Xen": {X: 3, Y: 0},
+			},
+			expectsError: false,
+		},
+		"inconsistent world": {
+			world: World{
+				"Foo": Roads{Direction_East: "Bar"},
+				"Bar": Roads{Direction_East: "Foo", Direction_West: "Foo"},
+			},
+			expectedLayout: Layout{},
+			expectsError:   true,
+		},
+		// Foo --- Bar
+		//          |
+		// Qu-ux - Baz
+		// (going north from Qu-ux leads to Kaa, which is placed where Foo is, and going east from Kaa leads to Xen,
+		// which is placed where Bar is)
+		"overlapping cities": {
+			world: World{
+				"Foo":   Roads{Direction_East: "Bar"},
+				"Bar":   Roads{Direction_West: "Foo", Direction_South: "Baz"},
+				"Baz":   Roads{Direction_North: "Bar", Direction_West: "Qu-ux"},
+				"Qu-ux": Roads{Direction_East: "Baz", Direction_North: "Kaa"},
+				"Kaa":   Roads{Direction_South: "Qu-ux", Direction_East: "Xen"},
+				"Xen":   Roads{Direction_West: "Kaa"},
+			},
+			expectedLayout: Layout{},
+			expectsError:   true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			layout, err := NewLayout(tc.world)
+
+			if tc.expectsError {
+				assert.Error(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expectedLayout, layout)
+			}
+		})
+	}
+}
+
+func Test_Bounds(t *testing.T) {
+	layout := Layout{
+		"Foo": {X: -1, Y: 3},
+		"Bar": {X: 2, Y: -4},
+		"Baz": {X: 0, Y: 0},
+	}
+
+	min, max := layout.Bounds()
+
+	assert.Equal(t, Coords{X: -1, Y: -4}, min)
+	assert.Equal(t, Coords{X: 2, Y: 3}, max)
+}
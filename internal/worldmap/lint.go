@@ -0,0 +1,392 @@
+package worldmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Severity tells how serious a problem found in a map file is.
+type Severity string
+
+const (
+	// Severity_Error is used for problems that make a map file invalid, i.e. that ReadFromFile would reject.
+	Severity_Error Severity = "error"
+	// Severity_Warning is used for problems that don't prevent a map file from being read but are likely mistakes.
+	Severity_Warning Severity = "warning"
+)
+
+// Diagnostic describes a problem found in a map file. Lines and columns are 1-based, and columns count bytes.
+// The problem spans from Column to EndColumn (both included) in Line.
+type Diagnostic struct {
+	Severity  Severity
+	Line      int
+	Column    int
+	EndColumn int
+	Message   string
+}
+
+// String implements the Stringer interface.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d-%d: %s: %s", d.Line, d.Column, d.EndColumn, d.Severity, d.Message)
+}
+
+// position locates a token in a map file.
+type position struct {
+	line      int
+	column    int
+	endColumn int
+}
+
+// linter holds the state needed to lint a map file.
+type linter struct {
+	world       World
+	diagnostics []Diagnostic
+	// declaredAt and mentionedAt hold the first position each city is declared at (as the first token of a line)
+	// or mentioned at (either declared or as the destination of a road), respectively.
+	declaredAt  map[string]position
+	mentionedAt map[string]position
+	metadata    Metadata
+}
+
+// Lint checks the map file read from r and returns every problem found in it, sorted by position. Unlike
+// ReadFromFile, it doesn't stop at the first problem. Errors are reported for anything ReadFromFile would reject:
+//
+//   - malformed roads, including the ones produced by repeated spaces.
+//   - trailing whitespace.
+//   - malformed, repeated or misplaced metadata, and bad metadata values.
+//   - unknown directions.
+//   - roads that conflict with roads declared before.
+//   - inconsistent worlds (see CheckConsistency).
+//
+// Warnings are reported for things that are accepted but likely to be mistakes:
+//
+//   - trailing whitespace in blank lines.
+//   - city names that don't follow the grammar of the map file format (see ReadFromFile).
+//   - cities declared in more than one line, and roads declared more than once.
+//   - isolated cities, i.e. cities with no roads.
+//   - disconnected islands, i.e. groups of cities that can't be reached from the rest of the world.
+func Lint(r io.Reader) ([]Diagnostic, error) {
+	l := &linter{
+		world:       World{},
+		declaredAt:  map[string]position{},
+		mentionedAt: map[string]position{},
+	}
+
+	lineNum := 1
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		l.lintLine(scanner.Text(), lineNum)
+		lineNum++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	l.lintCities()
+	l.lintConsistency()
+	l.lintIslands()
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		if l.diagnostics[i].Line != l.diagnostics[j].Line {
+			return l.diagnostics[i].Line < l.diagnostics[j].Line
+		}
+
+		return l.diagnostics[i].Column < l.diagnostics[j].Column
+	})
+
+	return l.diagnostics, nil
+}
+
+// report adds a new diagnostic at pos.
+func (l *linter) report(severity Severity, pos position, format string, v ...any) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Severity:  severity,
+		Line:      pos.line,
+		Column:    pos.column,
+		EndColumn: pos.endColumn,
+		Message:   fmt.Sprintf(format, v...),
+	})
+}
+
+// lintLine lints a single line of a map file, adding the cities and roads declared in it to the linter's world.
+func (l *linter) lintLine(line string, lineNum int) {
+	switch {
+	case line == "" || isCommentLine(line):
+		return
+	case isBlankLine(line):
+		l.report(Severity_Warning, position{line: lineNum, column: 1, endColumn: len(line)}, "trailing whitespace")
+		return
+	case isMetadataLine(line):
+		l.lintMetadata(line, lineNum)
+		return
+	}
+
+	trimmed := strings.TrimRight(line, " \t")
+	if len(trimmed) < len(line) {
+		l.report(Severity_Error, position{line: lineNum, column: len(trimmed) + 1, endColumn: len(line)}, "trailing whitespace")
+		line = trimmed
+	}
+
+	tokens := strings.Split(line, " ")
+	positions := make([]position, len(tokens))
+	column := 1
+	for i, t := range tokens {
+		positions[i] = position{line: lineNum, column: column, endColumn: column + len(t) - 1}
+		column += len(t) + 1
+	}
+
+	cityName, cityPos := tokens[0], positions[0]
+	if cityName == "" {
+		// point at the whitespace that precedes the city name
+		cityPos.endColumn = cityPos.column
+		l.report(Severity_Error, cityPos, "unexpected whitespace at the beginning of the line")
+		return
+	}
+
+	l.checkCityName(cityName, cityPos)
+	if prev, declared := l.declaredAt[cityName]; declared {
+		l.report(Severity_Warning, cityPos, "city %s is already declared at line %d", cityName, prev.line)
+	} else {
+		l.declaredAt[cityName] = cityPos
+	}
+	l.mention(cityName, cityPos)
+
+	if _, ok := l.world[cityName]; !ok {
+		l.world[cityName] = Roads{}
+	}
+
+	declaredRoads := map[string]bool{}
+	for i, road := range tokens[1:] {
+		pos := positions[i+1]
+		if road == "" {
+			// an empty token is produced by consecutive spaces
+			pos.endColumn = pos.column
+			l.report(Severity_Error, pos, "unexpected whitespace: roads must be separated by a single space")
+			continue
+		}
+
+		roadParts := strings.Split(road, "=")
+		if len(roadParts) != 2 || roadParts[0] == "" || roadParts[1] == "" {
+			l.report(Severity_Error, pos, "malformed road %q: roads must have the format <direction>=<city_name>", road)
+			continue
+		}
+
+		dir, dest := Direction(roadParts[0]), roadParts[1]
+		dirPos := position{line: lineNum, column: pos.column, endColumn: pos.column + len(dir) - 1}
+		destPos := position{line: lineNum, column: dirPos.endColumn + 2, endColumn: pos.endColumn}
+
+		if _, err := dir.opposite(); err != nil {
+			l.report(Severity_Error, dirPos, "unknown direction %q: it must be one of east, north, south or west", dir)
+			continue
+		}
+
+		l.checkCityName(dest, destPos)
+		l.mention(dest, destPos)
+
+		if declaredRoads[road] {
+			l.report(Severity_Warning, pos, "road %s is declared more than once in this line", road)
+			continue
+		}
+		declaredRoads[road] = true
+
+		l.addRoad(cityName, dir, dest, pos)
+	}
+}
+
+// lintMetadata lints a metadata line, adding the value it declares to the linter's metadata.
+func (l *linter) lintMetadata(line string, lineNum int) {
+	linePos := position{line: lineNum, column: 1, endColumn: len(line)}
+	if len(l.declaredAt) > 0 {
+		l.report(Severity_Error, linePos, "metadata must be declared before the first city")
+		return
+	}
+
+	key, value, ok := splitMetadataLine(line)
+	if !ok {
+		l.report(Severity_Error, linePos, "malformed metadata %q: metadata must have the format @<key> <value>", line)
+		return
+	}
+
+	keyPos := position{line: lineNum, column: 2, endColumn: len(key) + 1}
+	if l.metadata.has(key) {
+		l.report(Severity_Error, keyPos, "metadata key %s is already declared", key)
+		return
+	}
+
+	if isMetadataCountKey(key) {
+		if _, ok := parseMetadataCount(value); !ok {
+			valuePos := position{line: lineNum, column: len(line) - len(value) + 1, endColumn: len(line)}
+			l.report(Severity_Error, valuePos, "bad value %q for metadata key %s: it must be a number greater than 0", value, key)
+			return
+		}
+	}
+
+	_ = l.metadata.set(line, lineNum)
+}
+
+// checkCityName reports a warning if name doesn't follow the grammar of the map file format.
+func (l *linter) checkCityName(name string, pos position) {
+	for _, c := range name {
+		isAlpha := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isAlpha && !isDigit {
+			l.report(Severity_Warning, pos, "city name %q should only contain letters and digits", name)
+			return
+		}
+	}
+}
+
+// mention records that city is mentioned at pos, if it wasn't mentioned before.
+func (l *linter) mention(city string, pos position) {
+	if _, mentioned := l.mentionedAt[city]; !mentioned {
+		l.mentionedAt[city] = pos
+	}
+}
+
+// addRoad adds a road, and the road in the opposite direction, to the linter's world, unless it conflicts with roads
+// already declared. pos is the position of the road declaration.
+func (l *linter) addRoad(city string, dir Direction, dest string, pos position) {
+	oppDir, _ := dir.opposite()
+
+	if d, alreadyExists := l.world[city][dir]; alreadyExists && d != dest {
+		l.report(
+			Severity_Error, pos,
+			"conflicting road: there is already a road from %s going %s to %s", city, dir, d,
+		)
+		return
+	}
+
+	if d, alreadyExists := l.world[dest][oppDir]; alreadyExists && d != city {
+		l.report(
+			Severity_Error, pos,
+			"conflicting road: there is already a road from %s going %s to %s", dest, oppDir, d,
+		)
+		return
+	}
+
+	l.world[city][dir] = dest
+	if _, ok := l.world[dest]; !ok {
+		l.world[dest] = Roads{}
+	}
+	l.world[dest][oppDir] = city
+}
+
+// lintCities reports cities with no roads.
+func (l *linter) lintCities() {
+	for _, c := range l.world.Cities() {
+		if len(l.world[c]) == 0 {
+			l.report(Severity_Warning, l.mentionedAt[c], "city %s is isolated: there are no roads to or from it", c)
+		}
+	}
+}
+
+// lintConsistency reports the problems that make the linter's world inconsistent.
+func (l *linter) lintConsistency() {
+	_, err := layoutIslands(l.world)
+	if err == nil {
+		return
+	}
+
+	consistencyErr, ok := err.(*ConsistencyError)
+	if !ok {
+		// invalid directions are already reported while linting lines
+		return
+	}
+
+	for _, c := range consistencyErr.Conflicts {
+		positions := []string{}
+		for _, p := range c.Positions {
+			positions = append(positions, p.String())
+		}
+
+		l.report(
+			Severity_Error, l.mentionedAt[c.City],
+			"inconsistent world: %s is placed at %s (relative to %s)", c.City, strings.Join(positions, " and "), c.Origin,
+		)
+	}
+
+	for _, c := range consistencyErr.Collisions {
+		l.report(
+			Severity_Error, l.mentionedAt[c.Cities[len(c.Cities)-1]],
+			"inconsistent world: %s are all placed at %s (relative to %s)", strings.Join(c.Cities, ", "), c.Position, c.Origin,
+		)
+	}
+}
+
+// lintIslands reports every island of the linter's world but the first one, at the position where its first city is
+// mentioned. Isolated cities are already reported on their own, so they are not taken into account.
+func (l *linter) lintIslands() {
+	islands := [][]string{}
+	for _, island := range connectedCities(l.world) {
+		if len(island) > 1 {
+			islands = append(islands, island)
+		}
+	}
+
+	if len(islands) < 2 {
+		return
+	}
+
+	// islands are reported in the order they are mentioned for the first time
+	first := func(island []string) position {
+		pos := l.mentionedAt[island[0]]
+		for _, c := range island[1:] {
+			p := l.mentionedAt[c]
+			if p.line < pos.line || (p.line == pos.line && p.column < pos.column) {
+				pos = p
+			}
+		}
+
+		return pos
+	}
+
+	sort.SliceStable(islands, func(i, j int) bool {
+		pi, pj := first(islands[i]), first(islands[j])
+		return pi.line < pj.line || (pi.line == pj.line && pi.column < pj.column)
+	})
+
+	for _, island := range islands[1:] {
+		l.report(
+			Severity_Warning, first(island),
+			"disconnected island: the %d cities connected to %s can't be reached from the rest of the world",
+			len(island), island[0],
+		)
+	}
+}
+
+// connectedCities splits world into groups of cities connected to each other. Each group is sorted alphabetically,
+// and groups are sorted by their first city.
+func connectedCities(world World) [][]string {
+	visited := map[string]bool{}
+	groups := [][]string{}
+	for _, origin := range world.Cities() {
+		if visited[origin] {
+			continue
+		}
+
+		group := []string{}
+		visited[origin] = true
+		queue := []string{origin}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			group = append(group, current)
+
+			for _, dir := range Directions {
+				if dest, ok := world[current][dir]; ok && !visited[dest] {
+					visited[dest] = true
+					queue = append(queue, dest)
+				}
+			}
+		}
+
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+
+	return groups
+}
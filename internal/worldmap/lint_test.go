@@ -0,0 +1,143 @@
+package worldmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Lint(t *testing.T) {
+	testCases := map[string]struct {
+		mapFileContents     string
+		expectedDiagnostics []Diagnostic
+	}{
+		"valid map": {
+			mapFileContents:     "Foo north=Bar west=Baz south=Qux\nBar south=Foo west=Bee",
+			expectedDiagnostics: nil,
+		},
+		"malformed road": {
+			mapFileContents: "Foo Bar west=Baz north=",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Error, Line: 1, Column: 5, EndColumn: 7, Message: `malformed road "Bar": roads must have the format <direction>=<city_name>`},
+				{Severity: Severity_Error, Line: 1, Column: 18, EndColumn: 23, Message: `malformed road "north=": roads must have the format <direction>=<city_name>`},
+			},
+		},
+		"unknown direction": {
+			mapFileContents: "Foo southeast=Bar west=Baz",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Error, Line: 1, Column: 5, EndColumn: 13, Message: `unknown direction "southeast": it must be one of east, north, south or west`},
+			},
+		},
+		"conflicting road": {
+			mapFileContents: "Foo north=Bar west=Baz\nBar south=Qux west=Bee",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Error, Line: 2, Column: 5, EndColumn: 13, Message: "conflicting road: there is already a road from Bar going south to Foo"},
+			},
+		},
+		"whitespace": {
+			mapFileContents: "Foo north=Bar  west=Baz \n Bar\n   ",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Error, Line: 1, Column: 15, EndColumn: 15, Message: "unexpected whitespace: roads must be separated by a single space"},
+				{Severity: Severity_Error, Line: 1, Column: 24, EndColumn: 24, Message: "trailing whitespace"},
+				{Severity: Severity_Error, Line: 2, Column: 1, EndColumn: 1, Message: "unexpected whitespace at the beginning of the line"},
+				{Severity: Severity_Warning, Line: 3, Column: 1, EndColumn: 3, Message: "trailing whitespace"},
+			},
+		},
+		"comments and metadata": {
+			mapFileContents:     "# The whole world\n@name Earth\n@aliens 2\n\n# Foo is the capital\nFoo north=Bar\n\nBar south=Foo\n",
+			expectedDiagnostics: nil,
+		},
+		"bad metadata": {
+			mapFileContents: "@name\n@aliens many\n@author Me\n@author You\nFoo north=Bar\n@max-iterations 10",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Error, Line: 1, Column: 1, EndColumn: 5, Message: `malformed metadata "@name": metadata must have the format @<key> <value>`},
+				{Severity: Severity_Error, Line: 2, Column: 9, EndColumn: 12, Message: `bad value "many" for metadata key aliens: it must be a number greater than 0`},
+				{Severity: Severity_Error, Line: 4, Column: 2, EndColumn: 7, Message: "metadata key author is already declared"},
+				{Severity: Severity_Error, Line: 6, Column: 1, EndColumn: 18, Message: "metadata must be declared before the first city"},
+			},
+		},
+		"city names": {
+			mapFileContents: "Foo north=Qu-ux\nBar_1 south=Baz",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Warning, Line: 1, Column: 11, EndColumn: 15, Message: `city name "Qu-ux" should only contain letters and digits`},
+				{Severity: Severity_Warning, Line: 2, Column: 1, EndColumn: 5, Message: `city name "Bar_1" should only contain letters and digits`},
+				{Severity: Severity_Warning, Line: 2, Column: 1, EndColumn: 5, Message: "disconnected island: the 2 cities connected to Bar_1 can't be reached from the rest of the world"},
+			},
+		},
+		"duplicate declarations": {
+			mapFileContents: "Foo north=Bar north=Bar\nBar south=Foo\nFoo",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Warning, Line: 1, Column: 15, EndColumn: 23, Message: "road north=Bar is declared more than once in this line"},
+				{Severity: Severity_Warning, Line: 3, Column: 1, EndColumn: 3, Message: "city Foo is already declared at line 1"},
+			},
+		},
+		"isolated cities and islands": {
+			mapFileContents: "Foo north=Bar\nBaz\nKaa east=Xen\nMuo west=Qux",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Warning, Line: 2, Column: 1, EndColumn: 3, Message: "city Baz is isolated: there are no roads to or from it"},
+				{Severity: Severity_Warning, Line: 3, Column: 1, EndColumn: 3, Message: "disconnected island: the 2 cities connected to Kaa can't be reached from the rest of the world"},
+				{Severity: Severity_Warning, Line: 4, Column: 1, EndColumn: 3, Message: "disconnected island: the 2 cities connected to Muo can't be reached from the rest of the world"},
+			},
+		},
+		"inconsistent world": {
+			mapFileContents: "Foo east=Bar\nBar east=Foo",
+			expectedDiagnostics: []Diagnostic{
+				{Severity: Severity_Error, Line: 1, Column: 1, EndColumn: 3, Message: "inconsistent world: Foo is placed at (1, 0) and (-1, 0) (relative to Bar)"},
+				{Severity: Severity_Error, Line: 1, Column: 10, EndColumn: 12, Message: "inconsistent world: Bar is placed at (0, 0) and (2, 0) (relative to Bar)"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			diagnostics, err := Lint(strings.NewReader(tc.mapFileContents))
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedDiagnostics, diagnostics)
+		})
+	}
+}
+
+func Test_Lint_errorsMatchReadFromFile(t *testing.T) {
+	mapFiles := []string{
+		"Foo north=Bar west=Baz south=Qu-ux\nBar south=Foo west=Bee",
+		"Foo Bar west=Baz south=Qu-ux",
+		"Foo southeast=Bar west=Baz south=Qu-ux",
+		"Foo north=Bar west=Baz south=Qu-ux\nBar south=Qu-ux west=Bee",
+		"Foo north=Bar west=Baz north=Bar",
+		"Foo east=Bar\nBar east=Foo west=Foo",
+		"Foo north=Bar \nBar",
+		"@name Earth\n# comment\n\nFoo north=Bar\n  \n",
+		"@name\nFoo north=Bar",
+		"@aliens many\nFoo north=Bar",
+		"@author Me\n@author You\nFoo north=Bar",
+		"Foo north=Bar\n@name Earth",
+	}
+
+	tmpDir := t.TempDir()
+	for i, contents := range mapFiles {
+		path, err := writeTestFile(tmpDir, "lint", contents)
+		assert.Nil(t, err)
+
+		_, readErr := ReadFromFile(path)
+
+		diagnostics, err := Lint(strings.NewReader(contents))
+		assert.Nil(t, err)
+
+		hasErrors := false
+		for _, d := range diagnostics {
+			if d.Severity == Severity_Error {
+				hasErrors = true
+			}
+		}
+
+		assert.Equal(t, readErr != nil, hasErrors, "map file %d", i)
+	}
+}
+
+func Test_Diagnostic_String(t *testing.T) {
+	d := Diagnostic{Severity: Severity_Error, Line: 3, Column: 5, EndColumn: 13, Message: "bad things"}
+
+	assert.Equal(t, "3:5-13: error: bad things", d.String())
+}
@@ -0,0 +1,150 @@
+package worldmap
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metadata keys with a special meaning. Any other key found in a map file is kept in Metadata.Extra.
+const (
+	MetadataKey_Name          = "name"
+	MetadataKey_Author        = "author"
+	MetadataKey_Aliens        = "aliens"
+	MetadataKey_MaxIterations = "max-iterations"
+)
+
+// Metadata holds the information declared in the header of a map file, in '@<key> <value>' lines.
+type Metadata struct {
+	// Name is the name of the map.
+	Name string
+	// Author is whoever created the map.
+	Author string
+	// Aliens is the recommended number of aliens to unleash in the map, or 0 if there is no recommendation.
+	Aliens int
+	// MaxIterations is the recommended maximum number of iterations of simulations of the map, or 0 if there is no
+	// recommendation.
+	MaxIterations int
+	// Extra holds the values of the keys without a special meaning.
+	Extra map[string]string
+}
+
+// IsZero reports whether m holds no information at all.
+func (m Metadata) IsZero() bool {
+	return m.Name == "" && m.Author == "" && m.Aliens == 0 && m.MaxIterations == 0 && len(m.Extra) == 0
+}
+
+// set parses a single metadata line and stores its value in m. Keys can't be declared more than once.
+func (m *Metadata) set(line string, lineNum int) error {
+	key, value, ok := splitMetadataLine(line)
+	if !ok {
+		return fmt.Errorf("malformed metadata at line %d: %s", lineNum, line)
+	}
+
+	if m.has(key) {
+		return fmt.Errorf("duplicate metadata key at line %d: %s", lineNum, key)
+	}
+
+	switch key {
+	case MetadataKey_Name:
+		m.Name = value
+	case MetadataKey_Author:
+		m.Author = value
+	case MetadataKey_Aliens, MetadataKey_MaxIterations:
+		n, ok := parseMetadataCount(value)
+		if !ok {
+			return fmt.Errorf("bad value for metadata key %s at line %d: %s is not a number greater than 0", key, lineNum, value)
+		}
+
+		if key == MetadataKey_Aliens {
+			m.Aliens = n
+		} else {
+			m.MaxIterations = n
+		}
+	default:
+		if m.Extra == nil {
+			m.Extra = map[string]string{}
+		}
+		m.Extra[key] = value
+	}
+
+	return nil
+}
+
+// has reports whether a value for key is already stored in m.
+func (m *Metadata) has(key string) bool {
+	switch key {
+	case MetadataKey_Name:
+		return m.Name != ""
+	case MetadataKey_Author:
+		return m.Author != ""
+	case MetadataKey_Aliens:
+		return m.Aliens != 0
+	case MetadataKey_MaxIterations:
+		return m.MaxIterations != 0
+	default:
+		_, ok := m.Extra[key]
+		return ok
+	}
+}
+
+// splitMetadataLine splits a metadata line into its key and value. It reports false if the line is malformed, i.e. if
+// either the key or the value is missing.
+func splitMetadataLine(line string) (string, string, bool) {
+	key, value, found := strings.Cut(strings.TrimPrefix(line, "@"), " ")
+	value = strings.TrimSpace(value)
+	if !found || key == "" || value == "" {
+		return "", "", false
+	}
+
+	return key, value, true
+}
+
+// isMetadataCountKey reports whether the value of key must be a number greater than 0.
+func isMetadataCountKey(key string) bool {
+	return key == MetadataKey_Aliens || key == MetadataKey_MaxIterations
+}
+
+// parseMetadataCount parses the value of a key whose value must be a number greater than 0, reporting false if it
+// isn't.
+func parseMetadataCount(value string) (int, bool) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// write writes m as a header block of metadata lines to w. Keys with a special meaning come first, followed by the
+// rest in alphabetical order.
+func (m Metadata) write(w *bufio.Writer) {
+	writeLine := func(key, value string) {
+		w.WriteString("@" + key + " " + value + "\n")
+	}
+
+	if m.Name != "" {
+		writeLine(MetadataKey_Name, m.Name)
+	}
+	if m.Author != "" {
+		writeLine(MetadataKey_Author, m.Author)
+	}
+	if m.Aliens != 0 {
+		writeLine(MetadataKey_Aliens, strconv.Itoa(m.Aliens))
+	}
+	if m.MaxIterations != 0 {
+		writeLine(MetadataKey_MaxIterations, strconv.Itoa(m.MaxIterations))
+	}
+
+	keys := make([]string, 0, len(m.Extra))
+	for k := range m.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		writeLine(k, m.Extra[k])
+	}
+}
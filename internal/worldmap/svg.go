@@ -0,0 +1,101 @@
+package worldmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// svgCellSize is the size, in pixels, of the square cell every city is drawn in.
+	svgCellSize = 100
+	// svgCityWidth and svgCityHeight are the dimensions, in pixels, of the boxes cities are drawn as.
+	svgCityWidth  = 80
+	svgCityHeight = 40
+	// svgAlienRadius is the radius, in pixels, of the dots aliens are drawn as.
+	svgAlienRadius = 6
+)
+
+// EncodeSVG writes world to out as a self-contained SVG image. Cities are drawn as labelled boxes placed according to
+// the layout in opts, with roads as lines between them. Aliens are drawn as red dots inside the cities they are at
+// (hovering over them shows their names) and destroyed cities are filled in black.
+func EncodeSVG(out io.Writer, world World, opts EncodeOptions) error {
+	layout, err := encodingLayout(world, opts)
+	if err != nil {
+		return err
+	}
+
+	aliensAt := aliensByCity(opts.Aliens)
+
+	min, max := layout.Bounds()
+	width := (max.X - min.X + 1) * svgCellSize
+	height := (max.Y - min.Y + 1) * svgCellSize
+	if len(layout) == 0 {
+		width, height = 0, 0
+	}
+
+	// center returns the center of the cell a city is drawn in
+	center := func(city string) (int, int) {
+		pos := layout[city]
+		return (pos.X-min.X)*svgCellSize + svgCellSize/2, (max.Y-pos.Y)*svgCellSize + svgCellSize/2
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf(
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height,
+	))
+	builder.WriteString(fmt.Sprintf("<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", width, height))
+
+	for _, r := range roads(world) {
+		x1, y1 := center(r[0])
+		x2, y2 := center(r[1])
+		builder.WriteString(fmt.Sprintf(
+			"<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"dimgrey\" stroke-width=\"3\"/>\n",
+			x1, y1, x2, y2,
+		))
+	}
+
+	for _, c := range layout.Cities() {
+		x, y := center(c)
+
+		fill, textFill := "lightgrey", "black"
+		if _, exists := world[c]; !exists {
+			fill, textFill = "black", "white"
+		}
+
+		builder.WriteString(fmt.Sprintf(
+			"<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"black\"/>\n",
+			x-svgCityWidth/2, y-svgCityHeight/2, svgCityWidth, svgCityHeight, fill,
+		))
+		builder.WriteString(fmt.Sprintf(
+			"<text x=\"%d\" y=\"%d\" fill=\"%s\" font-family=\"sans-serif\" font-size=\"12\" text-anchor=\"middle\">%s</text>\n",
+			x, y, textFill, escapeXML(c),
+		))
+
+		aliens := aliensAt[c]
+		for i, a := range aliens {
+			cx := x - (len(aliens)-1)*svgAlienRadius + i*2*svgAlienRadius
+			builder.WriteString(fmt.Sprintf(
+				"<circle cx=\"%d\" cy=\"%d\" r=\"%d\" fill=\"red\"><title>%s</title></circle>\n",
+				cx, y+svgCityHeight/4, svgAlienRadius, escapeXML(a),
+			))
+		}
+	}
+
+	builder.WriteString("</svg>\n")
+
+	_, err = io.WriteString(out, builder.String())
+
+	return err
+}
+
+// escapeXML escapes s so it can be used as text inside an XML element.
+func escapeXML(s string) string {
+	buf := bytes.Buffer{}
+	_ = xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
@@ -0,0 +1,66 @@
+package worldmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// svgImage holds the parts of an SVG image that are relevant for testing.
+type svgImage struct {
+	Width  int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+	Rects  []struct {
+		Fill string `xml:"fill,attr"`
+	} `xml:"rect"`
+	Lines   []struct{} `xml:"line"`
+	Texts   []string   `xml:"text"`
+	Circles []struct {
+		Title string `xml:"title"`
+	} `xml:"circle"`
+}
+
+func Test_EncodeSVG(t *testing.T) {
+	// Bar
+	//  |
+	// Foo --- Baz
+	world := World{
+		"Foo": Roads{Direction_North: "Bar", Direction_East: "Baz"},
+		"Bar": Roads{Direction_South: "Foo"},
+		"Baz": Roads{Direction_West: "Foo"},
+		"<&>": Roads{},
+	}
+
+	layout, err := NewLayout(world)
+	assert.Nil(t, err)
+
+	invaded := world.Copy()
+	invaded.DestroyCity("Foo")
+
+	out := &bytes.Buffer{}
+	err = EncodeSVG(out, invaded, EncodeOptions{Layout: layout, Aliens: map[string]string{"alien 0": "Bar"}})
+	assert.Nil(t, err)
+
+	img := svgImage{}
+	err = xml.Unmarshal(out.Bytes(), &img)
+	assert.Nil(t, err)
+
+	// "<&>" is laid out first, with an empty column between it and the rest of the world
+	assert.Equal(t, 4*svgCellSize, img.Width)
+	assert.Equal(t, 2*svgCellSize, img.Height)
+
+	// background plus one box per city, with the destroyed one in black
+	fills := []string{}
+	for _, r := range img.Rects {
+		fills = append(fills, r.Fill)
+	}
+	assert.Equal(t, []string{"white", "lightgrey", "lightgrey", "lightgrey", "black"}, fills)
+
+	// roads to Foo are gone
+	assert.Empty(t, img.Lines)
+	assert.Equal(t, []string{"<&>", "Bar", "Baz", "Foo"}, img.Texts)
+	assert.Len(t, img.Circles, 1)
+	assert.Equal(t, "alien 0", img.Circles[0].Title)
+}
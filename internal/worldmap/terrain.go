@@ -0,0 +1,42 @@
+package worldmap
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Terrain is what the simulation needs to know about a world to invade it. World is the Terrain of worlds read from
+// map files, but worlds that are too big to fit in memory can implement it too, e.g. by materializing cities on demand.
+type Terrain interface {
+	// Roads returns the roads that leave city, or an empty Roads if the city doesn't exist or has been destroyed. The
+	// returned Roads must not be modified.
+	Roads(city string) Roads
+	// DestroyCity destroys city, along with the roads that lead to and from it.
+	DestroyCity(city string)
+	// DestroyRoad destroys the road that leaves city going dir, along with the road back from its destination. Cities
+	// are left untouched.
+	DestroyRoad(city string, dir Direction)
+	// RandomCities returns n different cities chosen at random using rng, to place aliens in them. It returns an error
+	// if there are not enough cities to choose from.
+	RandomCities(n int, rng *rand.Rand) ([]string, error)
+}
+
+// Roads implements the Terrain interface.
+func (w World) Roads(city string) Roads {
+	return w[city]
+}
+
+// RandomCities implements the Terrain interface. Cities are sorted before shuffling them so that the result only
+// depends on the state of rng and not on the iteration order of the World.
+func (w World) RandomCities(n int, rng *rand.Rand) ([]string, error) {
+	if n > len(w) {
+		return nil, fmt.Errorf("not enough cities (%d) to place %d aliens", len(w), n)
+	}
+
+	cities := w.Cities()
+	rng.Shuffle(len(cities), func(i, j int) {
+		cities[i], cities[j] = cities[j], cities[i]
+	})
+
+	return cities[:n], nil
+}
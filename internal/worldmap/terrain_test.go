@@ -0,0 +1,61 @@
+package worldmap
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_World_RandomCities(t *testing.T) {
+	world := World{
+		"Foo": Roads{},
+		"Bar": Roads{},
+		"Baz": Roads{},
+	}
+
+	resultCounts := map[string]int{
+		"FooBarBaz": 0,
+		"FooBazBar": 0,
+		"BarFooBaz": 0,
+		"BarBazFoo": 0,
+		"BazFooBar": 0,
+		"BazBarFoo": 0,
+	}
+
+	// since the results from the function are random, we'll call it a given number of times and collect results.
+	// We will then check those results for statistical randomness
+	rng := rand.New(rand.NewSource(1))
+	numIterations := 2000
+	for i := 0; i < numIterations; i++ {
+		randCitites, err := world.RandomCities(len(world), rng)
+		assert.Nil(t, err)
+		resultCounts[strings.Join(randCitites, "")]++
+	}
+
+	// assert results are selected uniformly
+	assert.Condition(t, func() bool {
+		// allow 15% deviation
+		even := numIterations / len(resultCounts)
+		delta := even * 15 / 100
+		lowThreshold := even - delta
+		highThreshold := even + delta
+
+		for _, count := range resultCounts {
+			if count < lowThreshold || count > highThreshold {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+func Test_World_RandomCities_notEnoughCities(t *testing.T) {
+	world := World{"Foo": Roads{}, "Bar": Roads{}}
+
+	_, err := world.RandomCities(3, rand.New(rand.NewSource(1)))
+
+	assert.NotNil(t, err)
+}
@@ -2,9 +2,12 @@ package worldmap
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -24,6 +27,10 @@ const (
 	Direction_West  Direction = "west"
 )
 
+// Directions lists all the valid directions in a fixed order (clockwise, starting from north). It is meant to be used
+// whenever roads need to be traversed in a deterministic way.
+var Directions = []Direction{Direction_North, Direction_East, Direction_South, Direction_West}
+
 // opposite returns the opposite direction of the direction given, as seen from the destination.
 func (d Direction) opposite() (Direction, error) {
 	switch d {
@@ -45,46 +52,150 @@ func (d Direction) opposite() (Direction, error) {
 // with the cities that can be reached from it taking roads in different directions. Each of these lines has the format
 // '<city_name> [<road> [<road>]...]', where <city_name> is a string. <road> is a pair '<direction>=<destination_city_name>'.
 // <direction> can only be one of "east", "north", "south" and "west".
+// Lines starting with '#' are comments, and blank lines are ignored. A map file can start with a header block of
+// '@<key> <value>' lines holding its Metadata, which can't appear once the first city has been declared.
 //
 // This format can be expressed in EBNF notation as:
 //
-//	map file = city line , { city line } ;
+//	map file = { metadata line | comment line | blank line } , city line , { city line | comment line | blank line } ;
+//	metadata line = "@" , key , " " , value ;
+//	comment line = "#" , { any character } ;
+//	blank line = { " " | tab } ;
 //	city line = city name , {" " , road} ;
 //	city name = ( alpha | digit ) , { alpha | digit } ;
 //	road = direction , "=" , city name ;
 //	direction = "east" | "north" | "south" | "west" ;
+//
+// Map files can also be written in JSON or YAML (see ParseJSON and ParseYAML). The format of the file is detected
+// with DetectFileFormat.
 func ReadFromFile(path string) (World, error) {
-	file, err := os.Open(path)
+	mapFile, err := ParseFile(path)
 	if err != nil {
 		return World{}, err
 	}
-	defer file.Close()
 
-	world := World{}
-	lineNum := 1
-	scanner := bufio.NewScanner(file)
+	return mapFile.World, nil
+}
+
+// MapFile is a parsed map file. Besides the World it describes, it keeps track of how the file was written so it can
+// be formatted back in a similar way.
+type MapFile struct {
+	World World
+	// Order lists the cities in the order they are declared in the file. Cities that are never declared, but only
+	// mentioned as the destination of a road, come after the rest in the order they are first mentioned.
+	Order []string
+	// Metadata holds the information declared in the header of the file.
+	Metadata Metadata
+	// Comments holds the comments found in the file.
+	Comments Comments
+	// Layout holds the coordinates of the cities that have them declared in the file, or nil if none has. Only JSON and
+	// YAML map files can declare coordinates.
+	Layout Layout
+}
+
+// Comments holds the comment lines of a map file, including the leading '#', grouped by where they appear.
+type Comments struct {
+	// Header holds the comments that come before the first city declaration, except the ones right before it, which
+	// belong to the first city.
+	Header []string
+	// Cities holds, for every city, the comments between its declaration and the previous one.
+	Cities map[string][]string
+	// Footer holds the comments that come after the last city declaration.
+	Footer []string
+}
+
+// ParseFile parses the map file at path, detecting its format with DetectFileFormat.
+func ParseFile(path string) (*MapFile, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseFormat(bytes.NewReader(contents), DetectFileFormat(path, contents))
+}
+
+// Parse parses a map file read from r. See ReadFromFile for a description of the format of map files.
+func Parse(r io.Reader) (*MapFile, error) {
+	mapFile := &MapFile{World: World{}, Order: []string{}, Comments: Comments{Cities: map[string][]string{}}}
+	declared := map[string]bool{}
+	mentioned := map[string]bool{}
+	mentionedOrder := []string{}
+	comments := []string{}
+	lineNum := 0
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		if err := parseLine(world, scanner.Text(), lineNum); err != nil {
-			return World{}, err
+		line := scanner.Text()
+		lineNum++
+
+		// before the first city declaration, comments separated from it by blank or metadata lines belong to the header
+		inHeader := len(declared) == 0
+		switch {
+		case isBlankLine(line):
+			if inHeader {
+				mapFile.Comments.Header = append(mapFile.Comments.Header, comments...)
+				comments = []string{}
+			}
+			continue
+		case isCommentLine(line):
+			comments = append(comments, line)
+			continue
+		case isMetadataLine(line):
+			if !inHeader {
+				return nil, fmt.Errorf("metadata after the first city declaration at line %d: %s", lineNum, line)
+			}
+
+			if err := mapFile.Metadata.set(line, lineNum); err != nil {
+				return nil, err
+			}
+			mapFile.Comments.Header = append(mapFile.Comments.Header, comments...)
+			comments = []string{}
+			continue
 		}
 
-		lineNum++
+		if err := parseLine(mapFile.World, line, lineNum); err != nil {
+			return nil, err
+		}
+
+		if len(comments) > 0 {
+			city := lineCities(line)[0]
+			mapFile.Comments.Cities[city] = append(mapFile.Comments.Cities[city], comments...)
+			comments = []string{}
+		}
+
+		for i, c := range lineCities(line) {
+			if i == 0 && !declared[c] {
+				declared[c] = true
+				mapFile.Order = append(mapFile.Order, c)
+			}
+
+			if !mentioned[c] {
+				mentioned[c] = true
+				mentionedOrder = append(mentionedOrder, c)
+			}
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return World{}, err
+		return nil, err
 	}
 
-	consistent, err := isConsistent(world)
-	if err != nil {
-		return World{}, fmt.Errorf("consistency check error: %w", err)
+	if len(declared) == 0 {
+		mapFile.Comments.Header = append(mapFile.Comments.Header, comments...)
+	} else if len(comments) > 0 {
+		mapFile.Comments.Footer = comments
 	}
 
-	if !consistent {
-		return World{}, errors.New("the defined world is not consistent")
+	for _, c := range mentionedOrder {
+		if !declared[c] {
+			mapFile.Order = append(mapFile.Order, c)
+		}
 	}
 
-	return world, nil
+	if err := CheckConsistency(mapFile.World); err != nil {
+		return nil, err
+	}
+
+	return mapFile, nil
 }
 
 // parseLine parses a single line from a map file, and adds the declared city and its roads to the passed World object.
@@ -100,6 +211,9 @@ func parseLine(world World, line string, lineNum int) error {
 	parts := strings.Split(line, " ")
 
 	cityName := parts[0]
+	if cityName == "" {
+		return fmt.Errorf("unexpected whitespace at the beginning of line %d: %s", lineNum, line)
+	}
 
 	if _, ok := world[cityName]; !ok {
 		world[cityName] = Roads{}
@@ -121,86 +235,238 @@ func parseLine(world World, line string, lineNum int) error {
 
 		dest := roadParts[1]
 
-		// add the road to the origin city and also to the destination one, carefully checking for conflicts
-		if d, alreadyExists := world[cityName][dir]; alreadyExists {
-			if d != dest {
-				return fmt.Errorf(
-					"conflict in road declaration at line %d: a road from %s direction %s to %s is declared, but there is already a road in that direction to %s",
-					lineNum, cityName, dir, dest, d,
-				)
-			}
-		} else {
-			world[cityName][dir] = dest
+		if err := world.addRoad(cityName, dir, dest); err != nil {
+			return fmt.Errorf("conflict in road declaration at line %d: %w", lineNum, err)
 		}
+	}
+
+	return nil
+}
+
+// addRoad adds a road from city going dir to dest, and also the road in the opposite direction from dest, carefully
+// checking for conflicts with the roads already in the World. Declaring an existing road again is not a conflict.
+func (w World) addRoad(city string, dir Direction, dest string) error {
+	if _, ok := w[city]; !ok {
+		w[city] = Roads{}
+	}
 
-		if _, ok := world[dest]; !ok {
-			world[dest] = Roads{}
+	if d, alreadyExists := w[city][dir]; alreadyExists {
+		if d != dest {
+			return fmt.Errorf(
+				"a road from %s direction %s to %s is declared, but there is already a road in that direction to %s",
+				city, dir, dest, d,
+			)
 		}
+	} else {
+		w[city][dir] = dest
+	}
 
-		oppDir, _ := dir.opposite()
-		if d, alreadyExists := world[dest][oppDir]; alreadyExists {
-			if d != cityName {
-				return fmt.Errorf(
-					"conflict in road declaration at line %d: a road from %s direction %s to %s is declared, but the destination already has a road in the opposite direction to %s",
-					lineNum, cityName, dir, dest, d,
-				)
-			}
-		} else {
-			world[dest][oppDir] = cityName
+	if _, ok := w[dest]; !ok {
+		w[dest] = Roads{}
+	}
+
+	oppDir, _ := dir.opposite()
+	if d, alreadyExists := w[dest][oppDir]; alreadyExists {
+		if d != city {
+			return fmt.Errorf(
+				"a road from %s direction %s to %s is declared, but the destination already has a road in the opposite direction to %s",
+				city, dir, dest, d,
+			)
 		}
+	} else {
+		w[dest][oppDir] = city
 	}
 
 	return nil
 }
 
-// coords is a tuple that expresses the position of a city in a grid representation of a world
-type coords struct {
-	x int
-	y int
-}
+// lineCities returns the names of the cities mentioned in a valid map file line, in the order they appear.
+func lineCities(line string) []string {
+	if line == "" {
+		return nil
+	}
 
-// isConsistent checks the world for consistency. A world is consistent if every city appears at exactly one position
-// when the given world is represented in a grid.
-func isConsistent(world World) (bool, error) {
-	// start at any point in the map
-	var origin string
-	for c := range world {
-		origin = c
-		break
+	parts := strings.Split(line, " ")
+	cities := []string{parts[0]}
+	for _, road := range parts[1:] {
+		cities = append(cities, road[strings.Index(road, "=")+1:])
 	}
 
-	cMap := map[string]coords{}
+	return cities
+}
 
-	return checkConsistency(world, origin, cMap, 0, 0)
+// isBlankLine reports whether line is empty or made only of whitespace.
+func isBlankLine(line string) bool {
+	return strings.TrimLeft(line, " \t") == ""
 }
 
-// checkConsistency performs a consistency check on the sub-world starting from 'current'. It recursively traverses
-// the world, storing city coordinates in a grid representation. The check will fail if a city is seen
-// at two different locations.
-func checkConsistency(world World, current string, cMap map[string]coords, x, y int) (bool, error) {
-	if _, alreadyVisited := cMap[current]; alreadyVisited {
-		return cMap[current].x == x && cMap[current].y == y, nil
+// isCommentLine reports whether line is a comment.
+func isCommentLine(line string) bool {
+	return strings.HasPrefix(line, "#")
+}
+
+// isMetadataLine reports whether line declares a metadata key.
+func isMetadataLine(line string) bool {
+	return strings.HasPrefix(line, "@")
+}
+
+// CheckConsistency checks world for consistency. A world is consistent if, when it is represented in a grid, every city
+// appears at exactly one position and no two cities share the same position.
+//
+// Every island (group of cities connected to each other) is checked on its own, since they can't be placed relative to
+// each other. If world is not consistent, the returned error is a *ConsistencyError describing every problem found.
+func CheckConsistency(world World) error {
+	_, err := layoutIslands(world)
+
+	return err
+}
+
+// layoutIslands splits world into islands and lays each of them out in a grid, with its origin at (0, 0). Islands are
+// traversed breadth-first, starting from the first city in alphabetical order not visited yet, and taking roads in the
+// order given by Directions. This makes the result deterministic.
+func layoutIslands(world World) ([]Layout, error) {
+	placed := map[string]bool{}
+	islands := []Layout{}
+	consistencyErr := &ConsistencyError{}
+	for _, origin := range world.Cities() {
+		if placed[origin] {
+			continue
+		}
+
+		island := Layout{origin: Coords{X: 0, Y: 0}}
+		conflicts := map[string][]Coords{}
+		conflicting := []string{}
+		queue := []string{origin}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			placed[current] = true
+
+			pos := island[current]
+			for dir := range world[current] {
+				if _, _, err := nextCoords(pos.X, pos.Y, dir); err != nil {
+					return nil, fmt.Errorf("consistency check error: %w", err)
+				}
+			}
+
+			for _, dir := range Directions {
+				dest, ok := world[current][dir]
+				if !ok {
+					continue
+				}
+
+				x, y, _ := nextCoords(pos.X, pos.Y, dir)
+				next := Coords{X: x, Y: y}
+				destPos, visited := island[dest]
+				if !visited {
+					island[dest] = next
+					queue = append(queue, dest)
+					continue
+				}
+
+				if destPos != next {
+					if _, seen := conflicts[dest]; !seen {
+						conflicts[dest] = []Coords{destPos}
+						conflicting = append(conflicting, dest)
+					}
+					conflicts[dest] = appendCoords(conflicts[dest], next)
+				}
+			}
+		}
+
+		for _, c := range conflicting {
+			consistencyErr.Conflicts = append(
+				consistencyErr.Conflicts,
+				PositionConflict{City: c, Origin: origin, Positions: conflicts[c]},
+			)
+		}
+
+		consistencyErr.Collisions = append(consistencyErr.Collisions, findCollisions(island, origin)...)
+		islands = append(islands, island)
 	}
 
-	cMap[current] = coords{x: x, y: y}
+	if len(consistencyErr.Conflicts) > 0 || len(consistencyErr.Collisions) > 0 {
+		return nil, consistencyErr
+	}
 
-	for dir, dest := range world[current] {
-		nextX, nextY, err := nextCoords(x, y, dir)
-		if err != nil {
-			return false, err
+	return islands, nil
+}
+
+// findCollisions returns the positions in island where more than one city is placed. origin is the city island was
+// laid out from.
+func findCollisions(island Layout, origin string) []Collision {
+	occupants := map[Coords][]string{}
+	for _, c := range island.Cities() {
+		occupants[island[c]] = append(occupants[island[c]], c)
+	}
+
+	collisions := []Collision{}
+	for _, c := range island.Cities() {
+		pos := island[c]
+		if cities := occupants[pos]; len(cities) > 1 && cities[0] == c {
+			collisions = append(collisions, Collision{Position: pos, Origin: origin, Cities: cities})
 		}
+	}
+
+	return collisions
+}
 
-		consistent, err := checkConsistency(world, dest, cMap, nextX, nextY)
-		if err != nil {
-			return false, err
+// appendCoords appends pos to positions unless it is already there.
+func appendCoords(positions []Coords, pos Coords) []Coords {
+	for _, p := range positions {
+		if p == pos {
+			return positions
 		}
+	}
+
+	return append(positions, pos)
+}
+
+// ConsistencyError describes why a world is not consistent.
+type ConsistencyError struct {
+	// Conflicts lists the cities that would need to be at more than one position.
+	Conflicts []PositionConflict
+	// Collisions lists the positions where more than one city would need to be.
+	Collisions []Collision
+}
+
+// PositionConflict describes a city that would need to be at more than one position, depending on the roads taken to
+// reach it. Positions are relative to Origin, the city its island was laid out from, which is at (0, 0).
+type PositionConflict struct {
+	City      string
+	Origin    string
+	Positions []Coords
+}
+
+// Collision describes a position where more than one city would need to be. Position is relative to Origin, the city
+// its island was laid out from, which is at (0, 0).
+type Collision struct {
+	Position Coords
+	Origin   string
+	Cities   []string
+}
 
-		if !consistent {
-			return false, nil
+// Error implements the error interface.
+func (e *ConsistencyError) Error() string {
+	problems := []string{}
+	for _, c := range e.Conflicts {
+		positions := []string{}
+		for _, p := range c.Positions {
+			positions = append(positions, p.String())
 		}
+
+		problems = append(problems, fmt.Sprintf(
+			"%s is placed at %s (relative to %s)", c.City, strings.Join(positions, " and "), c.Origin,
+		))
+	}
+
+	for _, c := range e.Collisions {
+		problems = append(problems, fmt.Sprintf(
+			"%s are all placed at %s (relative to %s)", strings.Join(c.Cities, ", "), c.Position, c.Origin,
+		))
 	}
 
-	return true, nil
+	return "the defined world is not consistent: " + strings.Join(problems, "; ")
 }
 
 // nextCoords calculates the coordinates of the city that would be reached if a road with direction dir was taken from
@@ -237,17 +503,50 @@ func (w World) DestroyCity(city string) {
 	delete(w, city)
 }
 
-// String implements the Stringer interface. It produces a representation of the given World instance in valid map
-// file format.
-func (w World) String() string {
-	builder := strings.Builder{}
+// DestroyRoad removes the road from city going dir, along with the road back from its destination, if there is any.
+func (w World) DestroyRoad(city string, dir Direction) {
+	dest, ok := w[city][dir]
+	if !ok {
+		return
+	}
+
+	delete(w[city], dir)
+	if oppDir, err := dir.opposite(); err == nil && w[dest][oppDir] == city {
+		delete(w[dest], oppDir)
+	}
+}
+
+// Copy returns a deep copy of the World, so that the copy can be mutated (e.g. by destroying cities) without affecting
+// the original.
+func (w World) Copy() World {
+	world := make(World, len(w))
 	for c, roads := range w {
-		builder.WriteString(c)
+		roadsCopy := make(Roads, len(roads))
 		for dir, dest := range roads {
-			builder.WriteString(fmt.Sprintf(" %s=%s", dir, dest))
+			roadsCopy[dir] = dest
 		}
-		builder.WriteString("\n")
+		world[c] = roadsCopy
 	}
 
+	return world
+}
+
+// Cities returns the names of the cities in the World in alphabetical order.
+func (w World) Cities() []string {
+	cities := make([]string, 0, len(w))
+	for c := range w {
+		cities = append(cities, c)
+	}
+	sort.Strings(cities)
+
+	return cities
+}
+
+// String implements the Stringer interface. It produces a representation of the given World instance in canonical map
+// file format (see Format), so the same World always produces the same output.
+func (w World) String() string {
+	builder := strings.Builder{}
+	_ = Format(&builder, w, FormatOptions{})
+
 	return builder.String()
 }
@@ -1,7 +1,9 @@
 package worldmap
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,11 @@ func Test_ReadFromFile(t *testing.T) {
 			expectedWorld:   nil,
 			expectsError:    true,
 		},
+		"leading whitespace": {
+			mapFileContents: " Foo north=Bar",
+			expectedWorld:   nil,
+			expectsError:    true,
+		},
 		"unsupported direction": {
 			mapFileContents: "Foo southeast=Bar west=Baz south=Qu-ux",
 			expectedWorld:   nil,
@@ -73,6 +80,50 @@ func Test_ReadFromFile(t *testing.T) {
 			expectedWorld:   nil,
 			expectsError:    true,
 		},
+		"comments, blank lines and metadata are not cities": {
+			mapFileContents: "@name Tiny\n# a comment\n\nFoo north=Bar\n  \n# another comment\nBar",
+			expectedWorld: World{
+				"Foo": Roads{
+					Direction_North: "Bar",
+				},
+				"Bar": Roads{
+					Direction_South: "Foo",
+				},
+			},
+			expectsError: false,
+		},
+		"JSON map file": {
+			mapFileContents: `{"cities": [{"name": "Foo", "roads": {"north": "Bar"}}]}`,
+			expectedWorld: World{
+				"Foo": Roads{
+					Direction_North: "Bar",
+				},
+				"Bar": Roads{
+					Direction_South: "Foo",
+				},
+			},
+			expectsError: false,
+		},
+		"metadata after the first city": {
+			mapFileContents: "Foo north=Bar\n@name Tiny",
+			expectedWorld:   nil,
+			expectsError:    true,
+		},
+		"malformed metadata": {
+			mapFileContents: "@name\nFoo north=Bar",
+			expectedWorld:   nil,
+			expectsError:    true,
+		},
+		"bad metadata value": {
+			mapFileContents: "@aliens -3\nFoo north=Bar",
+			expectedWorld:   nil,
+			expectsError:    true,
+		},
+		"duplicate metadata key": {
+			mapFileContents: "@name Tiny\n@name Small\nFoo north=Bar",
+			expectedWorld:   nil,
+			expectsError:    true,
+		},
 	}
 
 	tmpDir := t.TempDir()
@@ -94,6 +145,46 @@ func Test_ReadFromFile(t *testing.T) {
 	}
 }
 
+func Test_Parse_order(t *testing.T) {
+	mapFile, err := Parse(strings.NewReader("Foo north=Bar west=Baz\n\nQu-ux north=Baz\nBar south=Foo west=Bee"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"Foo", "Qu-ux", "Bar", "Baz", "Bee"}, mapFile.Order)
+}
+
+func Test_Parse_metadata(t *testing.T) {
+	mapFile, err := Parse(strings.NewReader(
+		"@name Tiny world\n@author Jane Doe\n@aliens 4\n@max-iterations 500\n@difficulty  hard \nFoo north=Bar",
+	))
+	assert.Nil(t, err)
+
+	expected := Metadata{
+		Name:          "Tiny world",
+		Author:        "Jane Doe",
+		Aliens:        4,
+		MaxIterations: 500,
+		Extra:         map[string]string{"difficulty": "hard"},
+	}
+	assert.Equal(t, expected, mapFile.Metadata)
+}
+
+func Test_Parse_comments(t *testing.T) {
+	mapFile, err := Parse(strings.NewReader(
+		"# header\n@name Tiny\n# more header\n\n# about Foo\nFoo north=Bar\n# about Bar\n\n# more about Bar\nBar\n# footer",
+	))
+	assert.Nil(t, err)
+
+	expected := Comments{
+		Header: []string{"# header", "# more header"},
+		Cities: map[string][]string{
+			"Foo": {"# about Foo"},
+			"Bar": {"# about Bar", "# more about Bar"},
+		},
+		Footer: []string{"# footer"},
+	}
+	assert.Equal(t, expected, mapFile.Comments)
+}
+
 // writeTestFile writes a temporary file with the given contents and returns its path.
 func writeTestFile(tmpDir, testName, contents string) (string, error) {
 	f, err := os.CreateTemp(tmpDir, testName)
@@ -110,7 +201,7 @@ func writeTestFile(tmpDir, testName, contents string) (string, error) {
 	return f.Name(), nil
 }
 
-func Test_isConsistent(t *testing.T) {
+func Test_CheckConsistency(t *testing.T) {
 	testCases := map[string]struct {
 		world              World
 		expectedConsistent bool
@@ -242,12 +333,100 @@ func Test_isConsistent(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			consistent, _ := isConsistent(tc.world)
-			assert.Equal(t, tc.expectedConsistent, consistent)
+			err := CheckConsistency(tc.world)
+			if tc.expectedConsistent {
+				assert.Nil(t, err)
+			} else {
+				assert.IsType(t, &ConsistencyError{}, err)
+			}
 		})
 	}
 }
 
+func Test_CheckConsistency_diagnostics(t *testing.T) {
+	testCases := map[string]struct {
+		world         World
+		expectedError *ConsistencyError
+	}{
+		// Aaa --- Bbb
+		//
+		// Foo --- Bar --- Foo
+		// (the inconsistent island is not the one containing the first city)
+		"inconsistent island": {
+			world: World{
+				"Aaa": Roads{Direction_East: "Bbb"},
+				"Bbb": Roads{Direction_West: "Aaa"},
+				"Foo": Roads{Direction_East: "Bar"},
+				"Bar": Roads{Direction_East: "Foo", Direction_West: "Foo"},
+			},
+			expectedError: &ConsistencyError{
+				Conflicts: []PositionConflict{
+					{City: "Foo", Origin: "Bar", Positions: []Coords{{X: 1, Y: 0}, {X: -1, Y: 0}}},
+					{City: "Bar", Origin: "Bar", Positions: []Coords{{X: 0, Y: 0}, {X: 2, Y: 0}}},
+				},
+			},
+		},
+		// Foo --- Bar
+		//          |
+		// Qu-ux - Baz
+		// (going north from Qu-ux leads to Kaa, which is placed where Foo is)
+		"colliding cities": {
+			world: World{
+				"Foo":   Roads{Direction_East: "Bar"},
+				"Bar":   Roads{Direction_West: "Foo", Direction_South: "Baz"},
+				"Baz":   Roads{Direction_North: "Bar", Direction_West: "Qu-ux"},
+				"Qu-ux": Roads{Direction_East: "Baz", Direction_North: "Kaa"},
+				"Kaa":   Roads{Direction_South: "Qu-ux"},
+			},
+			expectedError: &ConsistencyError{
+				Collisions: []Collision{
+					{Position: Coords{X: -1, Y: 0}, Origin: "Bar", Cities: []string{"Foo", "Kaa"}},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckConsistency(tc.world)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func Test_CheckConsistency_largeWorld(t *testing.T) {
+	// a single, very long road of cities
+	numCities := 200_000
+	world := World{}
+	for i := 0; i < numCities; i++ {
+		roads := Roads{}
+		if i > 0 {
+			roads[Direction_West] = fmt.Sprintf("C%d", i-1)
+		}
+		if i < numCities-1 {
+			roads[Direction_East] = fmt.Sprintf("C%d", i+1)
+		}
+		world[fmt.Sprintf("C%d", i)] = roads
+	}
+
+	assert.Nil(t, CheckConsistency(world))
+}
+
+func Test_ConsistencyError(t *testing.T) {
+	err := &ConsistencyError{
+		Conflicts: []PositionConflict{
+			{City: "Foo", Origin: "Bar", Positions: []Coords{{X: 1, Y: 0}, {X: -1, Y: 0}}},
+		},
+		Collisions: []Collision{
+			{Position: Coords{X: -1, Y: 0}, Origin: "Bar", Cities: []string{"Foo", "Kaa"}},
+		},
+	}
+
+	expected := "the defined world is not consistent: " +
+		"Foo is placed at (1, 0) and (-1, 0) (relative to Bar); Foo, Kaa are all placed at (-1, 0) (relative to Bar)"
+	assert.Equal(t, expected, err.Error())
+}
+
 func Test_DestroyCity(t *testing.T) {
 	testCases := map[string]struct {
 		world         World
@@ -311,6 +490,48 @@ func Test_DestroyCity(t *testing.T) {
 	}
 }
 
+func Test_DestroyRoad(t *testing.T) {
+	world := World{
+		"Foo": Roads{Direction_North: "Bar", Direction_West: "Baz"},
+		"Bar": Roads{Direction_South: "Foo"},
+		"Baz": Roads{Direction_East: "Foo"},
+	}
+
+	world.DestroyRoad("Foo", Direction_North)
+	// there is no road to destroy, so nothing changes
+	world.DestroyRoad("Baz", Direction_West)
+
+	assert.Equal(t, World{
+		"Foo": Roads{Direction_West: "Baz"},
+		"Bar": Roads{},
+		"Baz": Roads{Direction_East: "Foo"},
+	}, world)
+}
+
+func Test_Copy(t *testing.T) {
+	world := World{
+		"Foo": Roads{
+			Direction_North: "Bar",
+			Direction_West:  "Baz",
+		},
+		"Bar": Roads{
+			Direction_South: "Foo",
+		},
+		"Baz": Roads{
+			Direction_East: "Foo",
+		},
+	}
+
+	worldCopy := world.Copy()
+	assert.Equal(t, world, worldCopy)
+
+	// mutating the copy leaves the original untouched
+	worldCopy.DestroyCity("Foo")
+	assert.Contains(t, world, "Foo")
+	assert.Equal(t, Roads{Direction_South: "Foo"}, world["Bar"])
+	assert.Equal(t, Roads{Direction_East: "Foo"}, world["Baz"])
+}
+
 func Test_String(t *testing.T) {
 	testCases := map[string]struct {
 		world World
@@ -363,3 +584,27 @@ func Test_String(t *testing.T) {
 		})
 	}
 }
+
+func Test_String_isDeterministic(t *testing.T) {
+	world := World{
+		"Foo": Roads{
+			Direction_North: "Bar",
+			Direction_West:  "Baz",
+			Direction_South: "Qu-ux",
+		},
+		"Bar": Roads{
+			Direction_South: "Foo",
+		},
+		"Baz": Roads{
+			Direction_East: "Foo",
+		},
+		"Qu-ux": Roads{
+			Direction_North: "Foo",
+		},
+	}
+
+	expected := "Bar south=Foo\nBaz east=Foo\nFoo north=Bar south=Qu-ux west=Baz\nQu-ux north=Foo\n"
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, expected, world.String())
+	}
+}